@@ -1,9 +1,10 @@
 module github.com/Vinetwigs/vilmos/v2
 
-go 1.17
+go 1.18
 
 require (
 	github.com/urfave/cli/v2 v2.3.0
+	golang.org/x/image v0.24.0
 	gopkg.in/ini.v1 v1.63.2
 )
 
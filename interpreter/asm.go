@@ -0,0 +1,189 @@
+package interpreter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrorUnknownInstruction = errors.New("error: unknown instruction")
+	ErrorMissingLiteral     = errors.New("error: missing value after push or literal")
+	ErrorInvalidLiteral     = errors.New("error: push value must be an integer between 0 and 765 not used by an operation color")
+	ErrorRowLength          = errors.New("error: all the rows must have the same number of instructions")
+	ErrorEmptySource        = errors.New("error: no instructions to assemble")
+	ErrorReadSource         = errors.New("error: unable to read the source")
+)
+
+// Error returned when an assembly source can't be assembled
+type SyntaxError struct {
+	Line  int
+	Token string
+	Err   error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s [line: %d, token: %q]", e.Err.Error(), e.Line, e.Token)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+/*
+ * Assembles a textual program into a vilmos image.
+ * Each token is the name of an operation (case insensitive), "push N", where N is the number to push painted
+ * as a color whose values sum up to N, or "literal N", painted as a LITERAL followed by a pixel holding N in its color bits.
+ * Everything after a '#' or a ';' is a comment. Each line with instructions is a row of the image,
+ * so all of them must have the same number of instructions.
+ * Operations are painted with the colors of the given set and each instruction is a square of instructionSize pixels per side.
+ */
+func Assemble(r io.Reader, set *InstructionSet, instructionSize int) (*image.RGBA, error) {
+	if instructionSize <= 0 {
+		return nil, ErrorInvalidInstructionSize
+	}
+	var rows [][]Pixel
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		row, err := assembleLine(scanner.Text(), line, set)
+		if err != nil {
+			return nil, err
+		}
+		if len(row) == 0 {
+			continue
+		}
+		if len(rows) > 0 && len(row) != len(rows[0]) {
+			return nil, &SyntaxError{Line: line, Token: strings.TrimSpace(stripComment(scanner.Text())), Err: ErrorRowLength}
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, ErrorReadSource
+	}
+	if len(rows) == 0 {
+		return nil, ErrorEmptySource
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, len(rows[0])*instructionSize, len(rows)*instructionSize))
+	for y, row := range rows {
+		for x, p := range row {
+			c := color.RGBA{R: p.R, G: p.G, B: p.B, A: 255}
+			for dy := 0; dy < instructionSize; dy++ {
+				for dx := 0; dx < instructionSize; dx++ {
+					img.SetRGBA(x*instructionSize+dx, y*instructionSize+dy, c)
+				}
+			}
+		}
+	}
+
+	// The image must be a valid program, e.g. without unmatched loops
+	if _, err := Compile(img, set, instructionSize); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// Converts a line of source into the colors of its instructions
+func assembleLine(text string, line int, set *InstructionSet) ([]Pixel, error) {
+	var row []Pixel
+	tokens := strings.Fields(stripComment(text))
+	for t := 0; t < len(tokens); t++ {
+		name := strings.ToUpper(tokens[t])
+		if name == "PUSH" {
+			if t+1 >= len(tokens) {
+				return nil, &SyntaxError{Line: line, Token: tokens[t], Err: ErrorMissingLiteral}
+			}
+			t++
+			n, err := strconv.Atoi(tokens[t])
+			if err != nil {
+				return nil, &SyntaxError{Line: line, Token: tokens[t], Err: ErrorInvalidLiteral}
+			}
+			p, ok := literalPixel(n, set)
+			if !ok {
+				return nil, &SyntaxError{Line: line, Token: tokens[t], Err: ErrorInvalidLiteral}
+			}
+			row = append(row, p)
+			continue
+		}
+		if name == "LITERAL" {
+			if t+1 >= len(tokens) {
+				return nil, &SyntaxError{Line: line, Token: tokens[t], Err: ErrorMissingLiteral}
+			}
+			t++
+			n, err := strconv.Atoi(tokens[t])
+			if err != nil {
+				return nil, &SyntaxError{Line: line, Token: tokens[t], Err: ErrorLiteralRange}
+			}
+			value, ok := literalValuePixel(n)
+			if !ok {
+				return nil, &SyntaxError{Line: line, Token: tokens[t], Err: ErrorLiteralRange}
+			}
+			op, _ := set.Color(name)
+			row = append(row, op, value)
+			continue
+		}
+		if name == "FLOAT_LITERAL" {
+			if t+1 >= len(tokens) {
+				return nil, &SyntaxError{Line: line, Token: tokens[t], Err: ErrorMissingLiteral}
+			}
+			t++
+			f, err := strconv.ParseFloat(tokens[t], 64)
+			if err != nil {
+				return nil, &SyntaxError{Line: line, Token: tokens[t], Err: ErrorFloatLiteralRange}
+			}
+			mantissa, exponent, ok := floatLiteralPixels(f)
+			if !ok {
+				return nil, &SyntaxError{Line: line, Token: tokens[t], Err: ErrorFloatLiteralRange}
+			}
+			op, _ := set.Color(name)
+			row = append(row, op, mantissa, exponent)
+			continue
+		}
+		p, ok := set.Color(name)
+		if !ok {
+			return nil, &SyntaxError{Line: line, Token: tokens[t], Err: ErrorUnknownInstruction}
+		}
+		row = append(row, p)
+	}
+	return row, nil
+}
+
+// Removes the comment, if any, from a line of source
+func stripComment(text string) string {
+	if index := strings.IndexAny(text, "#;"); index >= 0 {
+		return text[:index]
+	}
+	return text
+}
+
+/*
+ * Returns a color whose red, green and blue values sum up to n and that is not used by any operation of the set.
+ * A gray as balanced as possible is preferred. The second value is false if there is no such color.
+ */
+func literalPixel(n int, set *InstructionSet) (Pixel, bool) {
+	if n < 0 || n > 3*255 {
+		return Pixel{}, false
+	}
+	balanced := Pixel{R: uint8((n + 2) / 3), G: uint8((n + 1) / 3), B: uint8(n / 3)}
+	if _, used := set.Operation(&balanced); !used {
+		return balanced, true
+	}
+	for r := 255; r >= 0; r-- {
+		for g := 255; g >= 0; g-- {
+			b := n - r - g
+			if b < 0 || b > 255 {
+				continue
+			}
+			p := Pixel{R: uint8(r), G: uint8(g), B: uint8(b)}
+			if _, used := set.Operation(&p); !used {
+				return p, true
+			}
+		}
+	}
+	return Pixel{}, false
+}
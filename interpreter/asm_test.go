@@ -0,0 +1,147 @@
+package interpreter
+
+import (
+	"errors"
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAssemble(t *testing.T) {
+	tests := []struct {
+		name            string
+		source          string
+		instructionSize int
+		want            []Instruction
+		wantBounds      image.Rectangle
+	}{
+		{
+			name:            "Single row",
+			source:          "push 10 push 20 sum output_int",
+			instructionSize: 1,
+			want:            []Instruction{{Op: OpPush, Value: 10}, {Op: OpPush, Value: 20}, {Op: OpSum}, {Op: OpOutputInt}},
+			wantBounds:      image.Rect(0, 0, 4, 1),
+		},
+		{
+			name: "Rows, comments and big instructions",
+			source: `# counts down from 3
+PUSH 3 WHILE DUP    ; loop body
+OUTPUT_INT push 1 SUB
+WHILE_END POP QUIT
+`,
+			instructionSize: 10,
+			want: []Instruction{
+				{Op: OpPush, Value: 3}, {Op: OpWhile}, {Op: OpDup},
+				{Op: OpOutputInt}, {Op: OpPush, Value: 1}, {Op: OpSub},
+				{Op: OpWhileEnd}, {Op: OpPop}, {Op: OpQuit},
+			},
+			wantBounds: image.Rect(0, 0, 30, 30),
+		},
+		{
+			name:            "Values matching operation colors",
+			source:          "push 225 push 681 push 0 push 764",
+			instructionSize: 1,
+			want:            []Instruction{{Op: OpPush, Value: 225}, {Op: OpPush, Value: 681}, {Op: OpPush, Value: 0}, {Op: OpPush, Value: 764}},
+			wantBounds:      image.Rect(0, 0, 4, 1),
+		},
+		{
+			name:            "Padding with no-operations",
+			source:          "push 3 output_int\nnop quit",
+			instructionSize: 1,
+			want:            []Instruction{{Op: OpPush, Value: 3}, {Op: OpOutputInt}, {Op: OpNop}, {Op: OpQuit}},
+			wantBounds:      image.Rect(0, 0, 2, 2),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Assemble(strings.NewReader(tt.source), NewInstructionSet(), tt.instructionSize)
+			if err != nil {
+				t.Fatalf("Assemble() error = %v", err)
+			}
+			if img.Bounds() != tt.wantBounds {
+				t.Errorf("Assemble() bounds = %v, want %v", img.Bounds(), tt.wantBounds)
+			}
+			program, err := Compile(img, NewInstructionSet(), tt.instructionSize)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			if !reflect.DeepEqual(program.code, tt.want) {
+				t.Errorf("Assemble() code = %v, want %v", program.code, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssemble_CustomColors(t *testing.T) {
+	set, err := LoadInstructionSet(writeTestConfig(t, "[Colors]\nSUM=0a0a0a\n"))
+	if err != nil {
+		t.Fatalf("LoadInstructionSet() error = %v", err)
+	}
+	img, err := Assemble(strings.NewReader("push 30 sum"), set, 1)
+	if err != nil {
+		t.Fatalf("Assemble() error = %v", err)
+	}
+	if got := *readPixel(img, 1, 0); got != (Pixel{R: 10, G: 10, B: 10}) {
+		t.Errorf("SUM color = %v, want custom color", got)
+	}
+	if got := *readPixel(img, 0, 0); got == (Pixel{R: 10, G: 10, B: 10}) {
+		t.Errorf("push 30 color = %v, it must not be the SUM color", got)
+	}
+}
+
+func TestAssemble_Errors(t *testing.T) {
+	tests := []struct {
+		name            string
+		source          string
+		instructionSize int
+		wantErr         error
+		wantLine        int
+	}{
+		{name: "Unknown instruction", source: "push 1\nfoo", instructionSize: 1, wantErr: ErrorUnknownInstruction, wantLine: 2},
+		{name: "Missing literal", source: "pop push", instructionSize: 1, wantErr: ErrorMissingLiteral, wantLine: 1},
+		{name: "Literal not a number", source: "push one", instructionSize: 1, wantErr: ErrorInvalidLiteral, wantLine: 1},
+		{name: "Literal too big", source: "push 766", instructionSize: 1, wantErr: ErrorInvalidLiteral, wantLine: 1},
+		{name: "Negative literal", source: "push -1", instructionSize: 1, wantErr: ErrorInvalidLiteral, wantLine: 1},
+		{name: "Literal only matching an operation", source: "push 765", instructionSize: 1, wantErr: ErrorInvalidLiteral, wantLine: 1},
+		{name: "Missing literal value", source: "pop literal", instructionSize: 1, wantErr: ErrorMissingLiteral, wantLine: 1},
+		{name: "Literal value too big", source: "literal 8388608", instructionSize: 1, wantErr: ErrorLiteralRange, wantLine: 1},
+		{name: "Literal value too small", source: "literal -8388609", instructionSize: 1, wantErr: ErrorLiteralRange, wantLine: 1},
+		{name: "Missing float literal value", source: "float_literal", instructionSize: 1, wantErr: ErrorMissingLiteral, wantLine: 1},
+		{name: "Float literal not a number", source: "float_literal pi", instructionSize: 1, wantErr: ErrorFloatLiteralRange, wantLine: 1},
+		{name: "Float literal too precise", source: "float_literal 3.14159265", instructionSize: 1, wantErr: ErrorFloatLiteralRange, wantLine: 1},
+		{name: "Infinite float literal", source: "float_literal inf", instructionSize: 1, wantErr: ErrorFloatLiteralRange, wantLine: 1},
+		{name: "Rows with different length", source: "pop pop\n\n# comment\npop", instructionSize: 1, wantErr: ErrorRowLength, wantLine: 4},
+		{name: "Empty source", source: "# nothing\n", instructionSize: 1, wantErr: ErrorEmptySource},
+		{name: "Invalid instruction size", source: "pop", instructionSize: 0, wantErr: ErrorInvalidInstructionSize},
+		{name: "Unmatched loop", source: "push 1 while", instructionSize: 1, wantErr: ErrorMissingEndLoop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Assemble(strings.NewReader(tt.source), NewInstructionSet(), tt.instructionSize)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Assemble() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var syntaxErr *SyntaxError
+			if errors.As(err, &syntaxErr) && syntaxErr.Line != tt.wantLine {
+				t.Errorf("Assemble() error line = %d, want %d", syntaxErr.Line, tt.wantLine)
+			}
+		})
+	}
+}
+
+func Test_literalPixel(t *testing.T) {
+	set := NewInstructionSet()
+	for n := 0; n < 765; n++ {
+		p, ok := literalPixel(n, set)
+		if !ok {
+			t.Fatalf("literalPixel(%d) found no color", n)
+		}
+		if sum := int(p.R) + int(p.G) + int(p.B); sum != n {
+			t.Fatalf("literalPixel(%d) = %v with sum %d", n, p, sum)
+		}
+		if op, used := set.Operation(&p); used {
+			t.Fatalf("literalPixel(%d) = %v is the color of %s", n, p, op)
+		}
+	}
+}
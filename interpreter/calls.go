@@ -0,0 +1,78 @@
+package interpreter
+
+import "errors"
+
+// Max number of nested CALLs when no other limit is set with WithMaxCallDepth
+const DefaultMaxCallDepth = 1024
+
+var (
+	ErrorCallDepth           = errors.New("error: max call depth exceeded")
+	ErrorReturnWithoutCall   = errors.New("error: trying to return without a call")
+	ErrorInvalidMaxCallDepth = errors.New("error: max call depth must be greater than 0")
+)
+
+/*
+ * Position of a CALL or of an IMPORT, from which the matching RETURN resumes the execution in the saved direction.
+ * module is the path of the imported module for the frames saved by an IMPORT.
+ */
+type callFrame struct {
+	pc        int
+	direction Direction
+	program   *Program
+	module    string
+}
+
+/*
+ * Saves the position of the CALL and moves the program counter to the name of the called LABEL,
+ * so that the routine starts from the instruction following its name. In directional mode the routine starts moving right.
+ * Labels not defined by the program are looked up in the routines of the imported modules.
+ */
+func call(i *Interpreter) error {
+	if len(i.callStack) >= i.maxCallDepth {
+		return ErrorCallDepth
+	}
+	program, label := i.program, i.program.jumps[i.pc]
+	if label < 0 {
+		r, ok := i.routines[i.program.code[i.pc+1].Value]
+		if !ok {
+			return ErrorUndefinedLabel
+		}
+		program, label = r.program, r.index
+	}
+	i.callStack = append(i.callStack, callFrame{pc: i.pc, direction: i.direction, program: i.program})
+	i.program, i.pc, i.direction = program, label+1, DirectionRight
+	return nil
+}
+
+/*
+ * Moves the program counter back to the last CALL, so that the execution continues after it skipping its name,
+ * or to the last IMPORT, whose module is then completely imported. Returns the frame of the CALL or of the IMPORT.
+ */
+func ret(i *Interpreter) (callFrame, error) {
+	if len(i.callStack) == 0 {
+		return callFrame{}, ErrorReturnWithoutCall
+	}
+	frame := i.callStack[len(i.callStack)-1]
+	i.callStack = i.callStack[:len(i.callStack)-1]
+	i.program, i.pc, i.direction = frame.program, frame.pc, frame.direction
+	if frame.module != "" {
+		i.modules[frame.module].imported = true
+	} else {
+		i.pc = skipName(i, frame.pc)
+	}
+	return frame, nil
+}
+
+/*
+ * Returns the index of the name following the LABEL or the CALL at the given index, if the name is the next instruction
+ * to execute, so that the execution continues after it: always in linear mode, only moving right in directional mode.
+ * Otherwise the name isn't on the path of the program and the given index is returned.
+ */
+func skipName(i *Interpreter, index int) int {
+	if i.mode == ModeDirectional {
+		if next, ok := i.program.Neighbor(index, i.direction); !ok || next != index+1 {
+			return index
+		}
+	}
+	return index + 1
+}
@@ -0,0 +1,131 @@
+package interpreter
+
+import (
+	"errors"
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompile_Labels(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		want    map[int]int
+		wantErr *CompileError
+	}{
+		{
+			name:   "Calls before and after the label",
+			source: "call push 7 quit label push 7 return call push 7",
+			want:   map[int]int{0: 3, 6: 3},
+		},
+		{
+			name:    "Label without name",
+			source:  "label output_int",
+			wantErr: &CompileError{Op: "LABEL", Pos: image.Point{X: 0, Y: 0}, Err: ErrorMissingLabelName},
+		},
+		{
+			name:    "Call at the end",
+			source:  "label push 1 call",
+			wantErr: &CompileError{Op: "CALL", Pos: image.Point{X: 2, Y: 0}, Err: ErrorMissingLabelName},
+		},
+		{
+			name:    "Duplicate label",
+			source:  "label push 1 label push 1",
+			wantErr: &CompileError{Op: "LABEL", Pos: image.Point{X: 2, Y: 0}, Err: ErrorDuplicateLabel},
+		},
+		{
+			name:    "Undefined label",
+			source:  "label push 1 call push 2",
+			wantErr: &CompileError{Op: "CALL", Pos: image.Point{X: 2, Y: 0}, Err: ErrorUndefinedLabel},
+		},
+		{
+			name:   "Label defined by an imported module",
+			source: "push 0 import call push 2",
+			want:   map[int]int{2: -1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Assemble(strings.NewReader(tt.source), NewInstructionSet(), 1)
+			if tt.wantErr != nil {
+				if !reflect.DeepEqual(err, tt.wantErr) {
+					t.Errorf("Assemble() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Assemble() error = %v", err)
+			}
+			program, err := Compile(img, NewInstructionSet(), 1)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			for index, want := range tt.want {
+				if got := program.jumps[index]; got != want {
+					t.Errorf("Compile() jumps[%d] = %d, want %d", index, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestInterpreter_RunCalls(t *testing.T) {
+	// Routine 1 counts down from the top of the stack calling itself until it is 0
+	countdown := "label push 1 dup output_int dup if push 1 sub call push 1 else pop end_if return"
+	tests := []struct {
+		name    string
+		opts    []Option
+		source  string
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "Call and return",
+			source: "push 3 call push 1 push 4 call push 1 quit nop\nlabel push 1 push 2 mul output_int return nop nop",
+			want:   "68\n",
+		},
+		{
+			name:   "Label reached without call",
+			source: "label push 1 push 5 output_int",
+			want:   "5",
+		},
+		{
+			name:   "Recursion",
+			source: "push 3 call push 1 quit " + countdown,
+			want:   "3210\n",
+		},
+		{
+			name:    "Max call depth",
+			opts:    []Option{WithMaxCallDepth(2)},
+			source:  "push 3 call push 1 quit " + countdown,
+			want:    "32",
+			wantErr: ErrorCallDepth,
+		},
+		{
+			name:    "Return without call",
+			source:  "push 1 return",
+			wantErr: ErrorReturnWithoutCall,
+		},
+		{
+			name:    "Infinite recursion",
+			source:  "label push 1 call push 1",
+			wantErr: ErrorCallDepth,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := runTestSource(t, tt.source, tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Interpreter.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if out != tt.want {
+				t.Errorf("Interpreter.Run() output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+	if _, err := NewInterpreterWithOptions(WithMaxCallDepth(0)); err != ErrorInvalidMaxCallDepth {
+		t.Errorf("WithMaxCallDepth() error = %v, want %v", err, ErrorInvalidMaxCallDepth)
+	}
+}
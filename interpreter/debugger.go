@@ -0,0 +1,238 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrorUnknownCommand   = errors.New("error: unknown debugger command")
+	ErrorInvalidArguments = errors.New("error: invalid arguments for the debugger command")
+	ErrorNoBreakpoint     = errors.New("error: no breakpoint with the given number")
+)
+
+const debuggerHelp = `Commands:
+  step [N]               executes N instructions (default 1). ENTER is the same as step
+  continue               runs until a breakpoint is reached or the program terminates
+  break X Y              stops before the instruction containing the pixel (X, Y) of the main program
+  break OPERATION        stops before each instruction with the given operation, e.g. break WHILE_END
+  break                  lists the breakpoints
+  delete N               removes the breakpoint number N
+  print stack            prints the content of the stack
+  print pc               prints the position and the instruction pointed by the program counter
+  print calls            prints the positions of the pending CALLs and IMPORTs, from the last one
+  print heap             prints the address, the value and the type of the heap cells that are not 0
+  quit                   terminates the program
+  help                   prints this message`
+
+// Instruction where the debugger stops: a position in the program or every instruction with the given operation
+type breakpoint struct {
+	index int
+	op    Opcode
+	byOp  bool
+}
+
+func (b breakpoint) matches(index int, ins Instruction) bool {
+	if b.byOp {
+		return ins.Op == b.op
+	}
+	return index == b.index
+}
+
+/*
+ * State of the interactive debugger used by Run when the debug flag is set.
+ * remaining is the number of instructions to execute before stopping again, -1 means until a breakpoint.
+ */
+type debugger struct {
+	breakpoints []breakpoint
+	remaining   int
+}
+
+// Returns true if the debugger must stop before executing the instruction pointed by the program counter
+func (d *debugger) shouldStop(i *Interpreter) bool {
+	for _, b := range d.breakpoints {
+		// Breakpoints by coordinates are positions in the main program, not in the imported modules
+		if (b.byOp || !inModule(i)) && b.matches(i.pc, i.program.code[i.pc]) {
+			return true
+		}
+	}
+	if d.remaining == 0 {
+		return true
+	}
+	if d.remaining > 0 {
+		d.remaining--
+	}
+	return false
+}
+
+/*
+ * Prints the state of the interpreter and reads commands until one of them resumes the execution,
+ * starting from the instruction where the debugger stopped. Returns true if the user asked to terminate the program.
+ * When the input ends no more commands can be given, so the program continues until it terminates.
+ */
+func (d *debugger) prompt(i *Interpreter, step int, message string) bool {
+	debug(i, step, message)
+	for {
+		fmt.Fprint(i.debugOutput, "\n(vilmos) ")
+		line, err := i.input.ReadString('\n')
+		if err == io.EOF && strings.TrimSpace(line) == "" {
+			d.breakpoints, d.remaining = nil, -1
+			return false
+		}
+		resume, quit, err := d.execute(i, strings.Fields(line))
+		if err != nil {
+			fmt.Fprintf(i.debugOutput, "\033[31m%s\033[0m", err.Error())
+			continue
+		}
+		if quit {
+			return true
+		}
+		if resume {
+			// The instruction where the debugger stopped is executed right after the prompt
+			if d.remaining > 0 {
+				d.remaining--
+			}
+			return false
+		}
+	}
+}
+
+// Executes a single debugger command. Returns whether the execution must be resumed and whether the program must be terminated.
+func (d *debugger) execute(i *Interpreter, args []string) (bool, bool, error) {
+	if len(args) == 0 {
+		d.remaining = 1
+		return true, false, nil
+	}
+	switch strings.ToLower(args[0]) {
+	case "step", "s":
+		steps := 1
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return false, false, ErrorInvalidArguments
+			}
+			steps = n
+		} else if len(args) > 2 {
+			return false, false, ErrorInvalidArguments
+		}
+		d.remaining = steps
+		return true, false, nil
+	case "continue", "c":
+		if len(args) != 1 {
+			return false, false, ErrorInvalidArguments
+		}
+		d.remaining = -1
+		return true, false, nil
+	case "break", "b":
+		return false, false, d.addBreakpoint(i, args[1:])
+	case "delete", "d":
+		if len(args) != 2 {
+			return false, false, ErrorInvalidArguments
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			return false, false, ErrorInvalidArguments
+		}
+		if n < 1 || n > len(d.breakpoints) {
+			return false, false, ErrorNoBreakpoint
+		}
+		d.breakpoints = append(d.breakpoints[:n-1], d.breakpoints[n:]...)
+		return false, false, nil
+	case "print", "p":
+		if len(args) != 2 {
+			return false, false, ErrorInvalidArguments
+		}
+		switch strings.ToLower(args[1]) {
+		case "stack":
+			printStack(i)
+		case "pc":
+			pos := i.program.Position(i.pc)
+			fmt.Fprintf(i.debugOutput, "pc: (%d, %d) -> %s", pos.X, pos.Y, i.program.code[i.pc])
+			if i.mode == ModeDirectional {
+				fmt.Fprintf(i.debugOutput, ", direction: %s", i.direction)
+			}
+		case "calls":
+			printCalls(i)
+		case "heap":
+			printHeap(i)
+		default:
+			return false, false, ErrorInvalidArguments
+		}
+		return false, false, nil
+	case "quit", "q":
+		return false, true, nil
+	case "help", "h":
+		fmt.Fprint(i.debugOutput, debuggerHelp)
+		return false, false, nil
+	}
+	return false, false, ErrorUnknownCommand
+}
+
+// Adds a breakpoint by pixel coordinates or by operation name. Without arguments the breakpoints are listed.
+func (d *debugger) addBreakpoint(i *Interpreter, args []string) error {
+	switch len(args) {
+	case 0:
+		lines := make([]string, len(d.breakpoints))
+		for n, b := range d.breakpoints {
+			if b.byOp {
+				lines[n] = fmt.Sprintf("%d: %s", n+1, b.op)
+			} else {
+				pos := mainProgram(i).Position(b.index)
+				lines[n] = fmt.Sprintf("%d: (%d, %d) -> %s", n+1, pos.X, pos.Y, mainProgram(i).code[b.index])
+			}
+		}
+		fmt.Fprint(i.debugOutput, strings.Join(lines, "\n"))
+		return nil
+	case 1:
+		op, ok := opcodesByName[strings.ToUpper(args[0])]
+		if !ok {
+			return ErrorUnknownInstruction
+		}
+		d.breakpoints = append(d.breakpoints, breakpoint{op: op, byOp: true})
+		return nil
+	case 2:
+		x, errX := strconv.Atoi(args[0])
+		y, errY := strconv.Atoi(args[1])
+		if errX != nil || errY != nil {
+			return ErrorInvalidArguments
+		}
+		index, ok := mainProgram(i).Index(image.Point{X: x, Y: y})
+		if !ok {
+			return ErrorOutOfBounds
+		}
+		d.breakpoints = append(d.breakpoints, breakpoint{index: index})
+		return nil
+	}
+	return ErrorInvalidArguments
+}
+
+/*
+ * Prints the positions of the pending CALLs and IMPORTs, from the last one.
+ * Each position belongs to the program of its frame, whose module is printed when it isn't the main program.
+ */
+func printCalls(i *Interpreter) {
+	for index := len(i.callStack) - 1; index >= 0; index-- {
+		frame := i.callStack[index]
+		target := frame.module // the IMPORTs print their module, the CALLs their name
+		if target == "" {
+			target = frame.program.code[frame.pc+1].String()
+		}
+		pos := frame.program.Position(frame.pc)
+		fmt.Fprintf(i.debugOutput, "\n(%d, %d) -> %s %s", pos.X, pos.Y, frame.program.code[frame.pc], target)
+		if frame.program != mainProgram(i) {
+			fmt.Fprintf(i.debugOutput, ", module: %s", frame.program.path)
+		}
+	}
+}
+
+// Returns the main program, whose coordinates are the ones of the breakpoints by position
+func mainProgram(i *Interpreter) *Program {
+	if i.main != nil {
+		return i.main
+	}
+	return i.program
+}
@@ -0,0 +1,207 @@
+package interpreter
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Counts down from 3 printing each number
+const debuggerTestSource = "push 3 while dup output_int push 1 sub while_end pop"
+
+var stepHeader = regexp.MustCompile(`Step (\d+) #`)
+
+func TestInterpreter_RunDebugger(t *testing.T) {
+	tests := []struct {
+		name         string
+		commands     string
+		wantStops    []string
+		wantOutput   string
+		wantDebugOut []string
+	}{
+		{
+			name:       "Step with ENTER",
+			commands:   "\n\n\n",
+			wantStops:  []string{"0", "1", "2", "3"},
+			wantOutput: "321",
+		},
+		{
+			name:       "Step N",
+			commands:   "step 4\nstep 2\n",
+			wantStops:  []string{"0", "4", "6"},
+			wantOutput: "321",
+		},
+		{
+			name:       "Continue without breakpoints",
+			commands:   "continue\n",
+			wantStops:  []string{"0"},
+			wantOutput: "321",
+		},
+		{
+			name:         "Breakpoint by operation",
+			commands:     "break SUB\nc\nc\ndelete 1\nc\n",
+			wantStops:    []string{"0", "5", "11"},
+			wantOutput:   "321",
+			wantDebugOut: []string{"Next: (5, 0) -> SUB"},
+		},
+		{
+			name:         "Breakpoint by coordinates",
+			commands:     "b 7 0\nbreak\nc\n",
+			wantStops:    []string{"0", "20"},
+			wantOutput:   "321",
+			wantDebugOut: []string{"1: (7, 0) -> POP", "Next: (7, 0) -> POP"},
+		},
+		{
+			name:         "Print stack and pc",
+			commands:     "step 3\nprint stack\nprint pc\nc\n",
+			wantStops:    []string{"0", "3"},
+			wantOutput:   "321",
+			wantDebugOut: []string{"|       3| int\n|       3| int", "pc: (3, 0) -> OUTPUT_INT"},
+		},
+		{
+			name:       "Quit",
+			commands:   "step 4\nquit\n",
+			wantStops:  []string{"0", "4"},
+			wantOutput: "3",
+		},
+		{
+			name:         "Invalid commands",
+			commands:     "jump\nstep 0\nbreak 100 0\nbreak FOO\ndelete 1\nprint registers\nc\n",
+			wantStops:    []string{"0"},
+			wantOutput:   "321",
+			wantDebugOut: []string{ErrorUnknownCommand.Error(), ErrorInvalidArguments.Error(), ErrorOutOfBounds.Error(), ErrorUnknownInstruction.Error(), ErrorNoBreakpoint.Error()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, debugOut bytes.Buffer
+			i, err := NewInterpreterWithOptions(
+				WithDebug(true),
+				WithInput(strings.NewReader(tt.commands)),
+				WithOutput(&out),
+				WithDebugOutput(&debugOut),
+			)
+			if err != nil {
+				t.Fatalf("NewInterpreterWithOptions() error = %v", err)
+			}
+			img, err := Assemble(strings.NewReader(debuggerTestSource), i.instructions, 1)
+			if err != nil {
+				t.Fatalf("Assemble() error = %v", err)
+			}
+			if i.program, err = Compile(img, i.instructions, 1); err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+
+			if _, err := i.Run(); err != nil {
+				t.Fatalf("Interpreter.Run() error = %v", err)
+			}
+			var stops []string
+			for _, match := range stepHeader.FindAllStringSubmatch(debugOut.String(), -1) {
+				stops = append(stops, match[1])
+			}
+			if strings.Join(stops, ",") != strings.Join(tt.wantStops, ",") {
+				t.Errorf("debugger stopped after steps %v, want %v", stops, tt.wantStops)
+			}
+			if out.String() != tt.wantOutput {
+				t.Errorf("Interpreter.Run() output = %q, want %q", out.String(), tt.wantOutput)
+			}
+			for _, want := range tt.wantDebugOut {
+				if !strings.Contains(debugOut.String(), want) {
+					t.Errorf("debug output doesn't contain %q:\n%s", want, debugOut.String())
+				}
+			}
+		})
+	}
+}
+
+func TestInterpreter_RunDebuggerCalls(t *testing.T) {
+	var out, debugOut bytes.Buffer
+	i, err := NewInterpreterWithOptions(
+		WithDebug(true),
+		WithInput(strings.NewReader("step 2\nprint calls\nc\n")),
+		WithOutput(&out),
+		WithDebugOutput(&debugOut),
+	)
+	if err != nil {
+		t.Fatalf("NewInterpreterWithOptions() error = %v", err)
+	}
+	img, err := Assemble(strings.NewReader("call push 1 quit label push 1 call push 2 label push 2 return"), i.instructions, 1)
+	if err != nil {
+		t.Fatalf("Assemble() error = %v", err)
+	}
+	if i.program, err = Compile(img, i.instructions, 1); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if _, err := i.Run(); err != nil {
+		t.Fatalf("Interpreter.Run() error = %v", err)
+	}
+	want := "(5, 0) -> CALL PUSH 2\n(0, 0) -> CALL PUSH 1"
+	if !strings.Contains(debugOut.String(), want) {
+		t.Errorf("debug output doesn't contain %q:\n%s", want, debugOut.String())
+	}
+}
+
+func TestInterpreter_RunDebuggerValueTypes(t *testing.T) {
+	var out, debugOut bytes.Buffer
+	i, err := NewInterpreterWithOptions(
+		WithDebug(true),
+		WithInput(strings.NewReader("step 2\nc\n")),
+		WithOutput(&out),
+		WithDebugOutput(&debugOut),
+	)
+	if err != nil {
+		t.Fatalf("NewInterpreterWithOptions() error = %v", err)
+	}
+	img, err := Assemble(strings.NewReader("float_literal 2.5 push 1 pop pop"), i.instructions, 1)
+	if err != nil {
+		t.Fatalf("Assemble() error = %v", err)
+	}
+	if i.program, err = Compile(img, i.instructions, 1); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if _, err := i.Run(); err != nil {
+		t.Fatalf("Interpreter.Run() error = %v", err)
+	}
+	want := "|       1| int\n|     2.5| float"
+	if !strings.Contains(debugOut.String(), want) {
+		t.Errorf("debug output doesn't contain %q:\n%s", want, debugOut.String())
+	}
+}
+
+func TestInterpreter_RunDebuggerModules(t *testing.T) {
+	dir := t.TempDir()
+	lib := writeTestModule(t, dir, "lib.png", "nop return label push 5 nop push 42 output_int return")
+	main := writeTestModule(t, dir, "main.png", pushString("lib.png")+" import call push 5 push 1 output_int")
+	var out, debugOut bytes.Buffer
+	i, err := NewInterpreterWithOptions(
+		WithDebug(true),
+		WithInput(strings.NewReader("break nop\nc\nprint calls\nstep 2\nc\nprint calls\nbreak 12 0\nc\nprint pc\nc\n")),
+		WithOutput(&out),
+		WithDebugOutput(&debugOut),
+	)
+	if err != nil {
+		t.Fatalf("NewInterpreterWithOptions() error = %v", err)
+	}
+	if err := i.LoadImage(main); err != nil {
+		t.Fatalf("Interpreter.LoadImage() error = %v", err)
+	}
+	if _, err := i.Run(); err != nil {
+		t.Fatalf("Interpreter.Run() error = %v", err)
+	}
+	if got, want := out.String(), "421"; got != want {
+		t.Errorf("Interpreter.Run() output = %q, want %q", got, want)
+	}
+	lib, _ = filepath.EvalSymlinks(lib)
+	for _, want := range []string{
+		"(vilmos) \n(8, 0) -> IMPORT " + lib + "\n(vilmos) ",
+		"Message: \033[33mImported module " + lib + "\033[0m",
+		"(vilmos) \n(9, 0) -> CALL PUSH 5\n(vilmos) ",
+		"pc: (12, 0) -> OUTPUT_INT",
+	} {
+		if !strings.Contains(debugOut.String(), want) {
+			t.Errorf("debug output doesn't contain %q:\n%s", want, debugOut.String())
+		}
+	}
+}
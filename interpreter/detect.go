@@ -0,0 +1,167 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"image"
+)
+
+const (
+	// Instruction size that makes Compile detect the size of the instructions from the image. See DetectInstructionSize.
+	AutoInstructionSize = -1
+	// A detected size can leave out of the square edges at most one color change out of misalignedEdgesRatio
+	misalignedEdgesRatio = 10
+)
+
+var (
+	ErrorInconsistentBlock = errors.New("error: instruction square is not uniformly colored")
+	ErrorEmptyImage        = errors.New("error: image has no pixels")
+)
+
+// Error returned when an instruction square contains a pixel with a different color from its upper-left corner
+type BlockError struct {
+	Block image.Point
+	Pixel image.Point
+	Size  int
+	Err   error
+}
+
+func (e *BlockError) Error() string {
+	return fmt.Sprintf("%s [square: (%d, %d), size: %d, pixel: (%d, %d)]", e.Err.Error(), e.Block.X, e.Block.Y, e.Size, e.Pixel.X, e.Pixel.Y)
+}
+
+func (e *BlockError) Unwrap() error {
+	return e.Err
+}
+
+/*
+ * Infers the instruction size of the image from the positions where the color changes, along the rows and along the columns.
+ * The candidate sizes are the ones dividing the positions of almost all the color changes, so that a few wrong pixels
+ * don't hide the size of the squares. The greatest candidate validated by ValidateInstructionSize is the size of the image,
+ * otherwise the instructions are single pixels.
+ * A *BlockError is returned instead when the squares of a candidate size are uniform except for a few wrong pixels.
+ * An image without color changes is a single instruction as big as possible.
+ * Notice that the size can't be detected if every instruction is followed by an equal one.
+ */
+func DetectInstructionSize(img image.Image) (int, error) {
+	width, height := img.Bounds().Max.X, img.Bounds().Max.Y
+	if width <= 0 || height <= 0 {
+		return 0, ErrorEmptyImage
+	}
+
+	// Number of color changes at each distance from the left or the top of the image
+	edges := make([]int, width+height)
+	total := 0
+	for y := 0; y < height; y++ {
+		for x := 1; x < width; x++ {
+			if !sameColor(img, x, y, x-1, y) {
+				edges[x]++
+				total++
+			}
+		}
+	}
+	for x := 0; x < width; x++ {
+		for y := 1; y < height; y++ {
+			if !sameColor(img, x, y, x, y-1) {
+				edges[y]++
+				total++
+			}
+		}
+	}
+	if total == 0 {
+		return gcd(width, height), nil
+	}
+
+	largest := width
+	if height < largest {
+		largest = height
+	}
+	var blockErr error
+	for candidate := largest; candidate > 1; candidate-- {
+		misaligned := 0
+		for position, count := range edges {
+			if position%candidate != 0 {
+				misaligned += count
+			}
+		}
+		if misaligned*misalignedEdgesRatio > total {
+			continue
+		}
+		err := ValidateInstructionSize(img, candidate)
+		if err == nil {
+			return candidate, nil
+		}
+		if blockErr == nil && almostUniform(img, candidate) {
+			blockErr = err
+		}
+	}
+	if blockErr != nil {
+		return 0, blockErr
+	}
+	return 1, nil
+}
+
+/*
+ * Returns true if at most one pixel out of misalignedEdgesRatio differs from the rest of its square, among the squares
+ * of the given size that are not uniform, as for a few wrong pixels in an image drawn with that size.
+ * The pixels differing in a square are the ones with a different color from its upper-left corner,
+ * or the ones with the same color when most of the square differs from the corner.
+ */
+func almostUniform(img image.Image, size int) bool {
+	width, height := img.Bounds().Max.X, img.Bounds().Max.Y
+	wrong, pixels := 0, 0
+	for top := 0; top < height; top += size {
+		for left := 0; left < width; left += size {
+			different, count := 0, 0
+			for y := top; y < top+size && y < height; y++ {
+				for x := left; x < left+size && x < width; x++ {
+					count++
+					if !sameColor(img, x, y, left, top) {
+						different++
+					}
+				}
+			}
+			if different == 0 {
+				continue
+			}
+			if different > count-different {
+				different = count - different
+			}
+			wrong += different
+			pixels += count
+		}
+	}
+	return wrong*misalignedEdgesRatio <= pixels
+}
+
+// Checks that every instruction square of the given size has a single color. Squares on the right and bottom edges can be cut.
+func ValidateInstructionSize(img image.Image, instructionSize int) error {
+	if instructionSize <= 0 {
+		return ErrorInvalidInstructionSize
+	}
+	width, height := img.Bounds().Max.X, img.Bounds().Max.Y
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			block := image.Point{X: x - x%instructionSize, Y: y - y%instructionSize}
+			if !sameColor(img, x, y, block.X, block.Y) {
+				return &BlockError{Block: block, Pixel: image.Point{X: x, Y: y}, Size: instructionSize, Err: ErrorInconsistentBlock}
+			}
+		}
+	}
+	return nil
+}
+
+// Returns true if the two pixels have the same color and the same alpha
+func sameColor(img image.Image, x1 int, y1 int, x2 int, y2 int) bool {
+	p1, alpha1 := readColor(img, x1, y1)
+	p2, alpha2 := readColor(img, x2, y2)
+	return *p1 == *p2 && alpha1 == alpha2
+}
+
+// Greatest common divisor. gcd(0, n) is n.
+func gcd(a int, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
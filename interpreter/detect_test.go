@@ -0,0 +1,106 @@
+package interpreter
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestDetectInstructionSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		size   int
+		want   int
+	}{
+		{name: "Single pixel instructions", source: "push 1 push 2 sum output_int", size: 1, want: 1},
+		{name: "Single row", source: "push 1 push 2 sum output_int", size: 10, want: 10},
+		{name: "Many rows", source: "push 3 while dup\noutput_int push 1 sub\nwhile_end pop quit", size: 7, want: 7},
+		{name: "Equal adjacent instructions", source: "dup dup sum\npop pop pop", size: 4, want: 4},
+		{name: "Single instruction", source: "quit", size: 5, want: 5},
+		{
+			// Only the change before OUTPUT_INT isn't at an even position, but the squares of size 2 are half and half
+			name:   "Single pixel instructions in pairs",
+			source: strings.Repeat("dup dup pop pop sum sum sub sub mul mul div div mod mod swap swap cycle cycle rcycle rcycle quit quit quit output_int\n", 4),
+			size:   1,
+			want:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Assemble(strings.NewReader(tt.source), NewInstructionSet(), tt.size)
+			if err != nil {
+				t.Fatalf("Assemble() error = %v", err)
+			}
+			got, err := DetectInstructionSize(img)
+			if err != nil {
+				t.Fatalf("DetectInstructionSize() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DetectInstructionSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectInstructionSize_InconsistentBlock(t *testing.T) {
+	source := strings.Repeat("push 1 push 2 sum pop\n", 4)
+	img, err := Assemble(strings.NewReader(source), NewInstructionSet(), 20)
+	if err != nil {
+		t.Fatalf("Assemble() error = %v", err)
+	}
+	img.Set(47, 65, color.RGBA{R: 255, A: 255})
+
+	_, err = DetectInstructionSize(img)
+	var blockErr *BlockError
+	if !errors.As(err, &blockErr) || !errors.Is(err, ErrorInconsistentBlock) {
+		t.Fatalf("DetectInstructionSize() error = %v, want *BlockError", err)
+	}
+	want := BlockError{Block: image.Point{X: 40, Y: 60}, Pixel: image.Point{X: 47, Y: 65}, Size: 20, Err: ErrorInconsistentBlock}
+	if *blockErr != want {
+		t.Errorf("DetectInstructionSize() error = %v, want %v", blockErr, &want)
+	}
+}
+
+func TestCompile_AutoInstructionSize(t *testing.T) {
+	img, _ := Assemble(strings.NewReader("push 30 push 12 sum output_int"), NewInstructionSet(), 6)
+	program, err := Compile(img, NewInstructionSet(), AutoInstructionSize)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if program.InstructionSize() != 6 || program.Len() != 4 {
+		t.Errorf("Compile() size = %d, len = %d, want 6 and 4", program.InstructionSize(), program.Len())
+	}
+	if _, err := Compile(image.NewRGBA(image.Rect(0, 0, 0, 0)), NewInstructionSet(), AutoInstructionSize); err != ErrorEmptyImage {
+		t.Errorf("Compile() of an empty image error = %v, want %v", err, ErrorEmptyImage)
+	}
+}
+
+func TestValidateInstructionSize(t *testing.T) {
+	// 3x3 squares, the ones on the right and bottom edges are cut
+	img := newTestImage(5, 4,
+		&Pixel{R: 1}, &Pixel{R: 1}, &Pixel{R: 1}, &Pixel{R: 2}, &Pixel{R: 2},
+		&Pixel{R: 1}, &Pixel{R: 1}, &Pixel{R: 1}, &Pixel{R: 2}, &Pixel{R: 2},
+		&Pixel{R: 1}, &Pixel{R: 1}, &Pixel{R: 1}, &Pixel{R: 2}, &Pixel{R: 2},
+		&Pixel{R: 3}, &Pixel{R: 3}, &Pixel{R: 3}, &Pixel{R: 4}, &Pixel{R: 4},
+	)
+	tests := []struct {
+		name    string
+		size    int
+		wantErr error
+	}{
+		{name: "Right size", size: 3},
+		{name: "Single pixels", size: 1},
+		{name: "Wrong size", size: 2, wantErr: ErrorInconsistentBlock},
+		{name: "Invalid size", size: 0, wantErr: ErrorInvalidInstructionSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateInstructionSize(img, tt.size); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateInstructionSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package interpreter
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrorInvalidExecutionMode = errors.New("error: invalid execution mode")
+	ErrorDirectionalOnly      = errors.New("error: instruction available only in directional mode")
+)
+
+// Order in which the instructions of a program are executed
+type ExecutionMode uint8
+
+const (
+	ModeLinear      ExecutionMode = iota // row by row, from the upper-left to the lower-right instruction (default)
+	ModeDirectional                      // following the direction pointer, which can be turned by the program
+)
+
+var executionModeNames = [...]string{
+	ModeLinear:      "linear",
+	ModeDirectional: "directional",
+}
+
+func (m ExecutionMode) String() string {
+	if int(m) < len(executionModeNames) {
+		return executionModeNames[m]
+	}
+	return "unknown"
+}
+
+// Returns the ExecutionMode with the given name: linear or directional
+func ParseExecutionMode(name string) (ExecutionMode, error) {
+	for mode, n := range executionModeNames {
+		if n == strings.ToLower(name) {
+			return ExecutionMode(mode), nil
+		}
+	}
+	return 0, ErrorInvalidExecutionMode
+}
+
+// Direction in which the program counter moves in directional mode. Directions are listed clockwise.
+type Direction uint8
+
+const (
+	DirectionRight Direction = iota // initial direction
+	DirectionDown
+	DirectionLeft
+	DirectionUp
+)
+
+var directionNames = [...]string{
+	DirectionRight: "right",
+	DirectionDown:  "down",
+	DirectionLeft:  "left",
+	DirectionUp:    "up",
+}
+
+func (d Direction) String() string {
+	if int(d) < len(directionNames) {
+		return directionNames[d]
+	}
+	return "unknown"
+}
+
+// Returns the direction turned by 90 degrees clockwise
+func (d Direction) Clockwise() Direction {
+	return (d + 1) % Direction(len(directionNames))
+}
+
+// Returns the direction turned by 90 degrees counterclockwise
+func (d Direction) Counterclockwise() Direction {
+	return (d + Direction(len(directionNames)) - 1) % Direction(len(directionNames))
+}
+
+// Returns the opposite direction
+func (d Direction) Reverse() Direction {
+	return (d + 2) % Direction(len(directionNames))
+}
+
+// Returns the column and row offsets of a move in the direction
+func (d Direction) offset() (int, int) {
+	switch d {
+	case DirectionRight:
+		return 1, 0
+	case DirectionDown:
+		return 0, 1
+	case DirectionLeft:
+		return -1, 0
+	default:
+		return 0, -1
+	}
+}
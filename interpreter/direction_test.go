@@ -0,0 +1,209 @@
+package interpreter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseExecutionMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    ExecutionMode
+		wantErr error
+	}{
+		{name: "linear", want: ModeLinear},
+		{name: "Directional", want: ModeDirectional},
+		{name: "piet", wantErr: ErrorInvalidExecutionMode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseExecutionMode(tt.name)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseExecutionMode() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseExecutionMode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if _, err := NewInterpreterWithOptions(WithExecutionMode(ExecutionMode(5))); err != ErrorInvalidExecutionMode {
+		t.Errorf("WithExecutionMode() error = %v, want %v", err, ErrorInvalidExecutionMode)
+	}
+}
+
+func TestDirection_Turns(t *testing.T) {
+	tests := []struct {
+		d                Direction
+		clockwise        Direction
+		counterclockwise Direction
+		reverse          Direction
+	}{
+		{d: DirectionRight, clockwise: DirectionDown, counterclockwise: DirectionUp, reverse: DirectionLeft},
+		{d: DirectionDown, clockwise: DirectionLeft, counterclockwise: DirectionRight, reverse: DirectionUp},
+		{d: DirectionLeft, clockwise: DirectionUp, counterclockwise: DirectionDown, reverse: DirectionRight},
+		{d: DirectionUp, clockwise: DirectionRight, counterclockwise: DirectionLeft, reverse: DirectionDown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.d.String(), func(t *testing.T) {
+			if got := tt.d.Clockwise(); got != tt.clockwise {
+				t.Errorf("Direction.Clockwise() = %v, want %v", got, tt.clockwise)
+			}
+			if got := tt.d.Counterclockwise(); got != tt.counterclockwise {
+				t.Errorf("Direction.Counterclockwise() = %v, want %v", got, tt.counterclockwise)
+			}
+			if got := tt.d.Reverse(); got != tt.reverse {
+				t.Errorf("Direction.Reverse() = %v, want %v", got, tt.reverse)
+			}
+		})
+	}
+}
+
+func TestProgram_Neighbor(t *testing.T) {
+	img, err := Assemble(strings.NewReader("nop nop nop\nnop nop nop"), NewInstructionSet(), 2)
+	if err != nil {
+		t.Fatalf("Assemble() error = %v", err)
+	}
+	program, err := Compile(img, NewInstructionSet(), 2)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	tests := []struct {
+		index     int
+		direction Direction
+		want      int
+		wantOk    bool
+	}{
+		{index: 0, direction: DirectionRight, want: 1, wantOk: true},
+		{index: 2, direction: DirectionRight, wantOk: false},
+		{index: 1, direction: DirectionDown, want: 4, wantOk: true},
+		{index: 4, direction: DirectionDown, wantOk: false},
+		{index: 3, direction: DirectionLeft, wantOk: false},
+		{index: 5, direction: DirectionUp, want: 2, wantOk: true},
+		{index: 0, direction: DirectionUp, wantOk: false},
+	}
+	for _, tt := range tests {
+		got, ok := program.Neighbor(tt.index, tt.direction)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("Program.Neighbor(%d, %v) = %d, %v, want %d, %v", tt.index, tt.direction, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func TestInterpreter_RunDirectional(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      ExecutionMode
+		source    string
+		want      string
+		wantStack int
+		wantErr   error
+	}{
+		{
+			name:   "Turns drawing a path",
+			mode:   ModeDirectional,
+			source: "push 1 push 2 turn_right\nquit nop sum\nnop output_int turn_right",
+			want:   "3",
+		},
+		{
+			name:   "Turn left",
+			mode:   ModeDirectional,
+			source: "push 1 turn_right nop nop\nnop push 2 nop nop\nnop turn_left sum output_int",
+			want:   "3",
+		},
+		{
+			name:      "Reflect",
+			mode:      ModeDirectional,
+			source:    "push 1 push 2 reflect",
+			wantStack: 4,
+		},
+		{
+			name:   "Turn if true",
+			mode:   ModeDirectional,
+			source: "push 1 turn_if output_int\nnop push 7 nop\nnop output_int nop",
+			want:   "7",
+		},
+		{
+			name:   "Turn if false",
+			mode:   ModeDirectional,
+			source: "push 5 push 0 turn_if output_int\nnop nop output_int nop",
+			want:   "5",
+		},
+		{
+			name:   "Literals moving right",
+			mode:   ModeDirectional,
+			source: "literal 1000 output_int float_literal 2.5 output_float",
+			want:   "10002.5",
+		},
+		{
+			name:    "Literal moving left",
+			mode:    ModeDirectional,
+			source:  "push 1 nop nop turn_right\noutput_int literal 5 turn_right",
+			wantErr: ErrorLiteralDirection,
+		},
+		{
+			name:    "Literal moving down",
+			mode:    ModeDirectional,
+			source:  "turn_right nop nop\nliteral 5 nop\noutput_int nop nop",
+			wantErr: ErrorLiteralDirection,
+		},
+		{
+			name:   "Call moving right",
+			mode:   ModeDirectional,
+			source: "call push 5 output_int quit label push 5 push 7 return",
+			want:   "7\n",
+		},
+		{
+			// The routine starts moving right and the program continues down from the CALL
+			name:   "Call moving down",
+			mode:   ModeDirectional,
+			source: "turn_right nop nop nop nop\ncall push 5 nop nop nop\noutput_int nop nop nop nop\nquit label push 5 push 42 return",
+			want:   "42\n",
+		},
+		{
+			name:   "Return restores the direction of the call",
+			mode:   ModeDirectional,
+			source: "call push 5 output_int quit label push 5 turn_right\nnop nop nop nop nop nop push 9\nnop nop nop nop nop nop return",
+			want:   "9\n",
+		},
+		{
+			name:   "Label moving down",
+			mode:   ModeDirectional,
+			source: "turn_right nop\nlabel push 3\npush 4 nop\noutput_int nop",
+			want:   "4",
+		},
+		{
+			name:   "Linear mode ignores the rows",
+			mode:   ModeLinear,
+			source: "push 1 push 2\nsum output_int",
+			want:   "3",
+		},
+		{
+			name:    "Turn in linear mode",
+			mode:    ModeLinear,
+			source:  "push 1 turn_right",
+			wantErr: ErrorDirectionalOnly,
+		},
+		{
+			name:    "Turn if on empty stack",
+			mode:    ModeDirectional,
+			source:  "turn_if",
+			wantErr: ErrorPop,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Programs looping because of a wrong path are stopped by the step limit
+			i, out, err := runTestSource(t, tt.source, WithExecutionMode(tt.mode), WithMaxSteps(1000))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Interpreter.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if out != tt.want {
+				t.Errorf("Interpreter.Run() output = %q, want %q", out, tt.want)
+			}
+			if got := i.stack.Size(); err == nil && got != tt.wantStack {
+				t.Errorf("stack size = %d, want %d", got, tt.wantStack)
+			}
+		})
+	}
+}
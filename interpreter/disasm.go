@@ -0,0 +1,40 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrorWriteListing = errors.New("error: unable to write the disassembled program")
+
+// Returns the mnemonic of the instruction: the operation name or "PUSH N", "LITERAL N" and "FLOAT_LITERAL X" for values
+func (ins Instruction) String() string {
+	switch ins.Op {
+	case OpPush, OpLiteral:
+		return fmt.Sprintf("%s %d", ins.Op, ins.Value)
+	case OpFloatLiteral:
+		return fmt.Sprintf("%s %s", ins.Op, FloatValue(ins.Float))
+	}
+	return ins.Op.String()
+}
+
+/*
+ * Writes the program as text, one instruction per line in execution order.
+ * Each line holds the row and the column of the instruction in the grid of instructions
+ * (not in pixels) followed by its mnemonic, so that two programs can be compared with a diff tool.
+ * The value pixels of a LITERAL or a FLOAT_LITERAL are part of its line.
+ */
+func (p *Program) Disassemble(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "# %4s %4s  %s\n", "row", "col", "instruction"); err != nil {
+		return ErrorWriteListing
+	}
+	for index := 0; index < len(p.code); index++ {
+		row, col := index/p.columns, index%p.columns
+		if _, err := fmt.Fprintf(w, "  %4d %4d  %s\n", row, col, p.code[index]); err != nil {
+			return ErrorWriteListing
+		}
+		index += literalValues(p.code[index].Op)
+	}
+	return nil
+}
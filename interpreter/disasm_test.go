@@ -0,0 +1,142 @@
+package interpreter
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProgram_Disassemble(t *testing.T) {
+	tests := []struct {
+		name            string
+		source          string
+		instructionSize int
+		want            string
+	}{
+		{
+			name:            "Single row",
+			source:          "push 30 push 12 sum output_int",
+			instructionSize: 1,
+			want: `#  row  col  instruction
+     0    0  PUSH 30
+     0    1  PUSH 12
+     0    2  SUM
+     0    3  OUTPUT_INT
+`,
+		},
+		{
+			name:            "Rows of big instructions",
+			source:          "push 1 while\npop while_end",
+			instructionSize: 4,
+			want: `#  row  col  instruction
+     0    0  PUSH 1
+     0    1  WHILE
+     1    0  POP
+     1    1  WHILE_END
+`,
+		},
+		{
+			name:            "Literals",
+			source:          "literal -1 literal 100000\npush 1 pop literal 0",
+			instructionSize: 1,
+			want: `#  row  col  instruction
+     0    0  LITERAL -1
+     0    2  LITERAL 100000
+     1    0  PUSH 1
+     1    1  POP
+     1    2  LITERAL 0
+`,
+		},
+		{
+			name:            "Float literals",
+			source:          "float_literal -0.5 float_literal 6.022e23 pop\nfloat_literal 100 nop nop nop nop",
+			instructionSize: 1,
+			want: `#  row  col  instruction
+     0    0  FLOAT_LITERAL -0.5
+     0    3  FLOAT_LITERAL 6.022e+23
+     0    6  POP
+     1    0  FLOAT_LITERAL 100.0
+     1    3  NOP
+     1    4  NOP
+     1    5  NOP
+     1    6  NOP
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Assemble(strings.NewReader(tt.source), NewInstructionSet(), tt.instructionSize)
+			if err != nil {
+				t.Fatalf("Assemble() error = %v", err)
+			}
+			program, err := Compile(img, NewInstructionSet(), tt.instructionSize)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			var out bytes.Buffer
+			if err := program.Disassemble(&out); err != nil {
+				t.Fatalf("Program.Disassemble() error = %v", err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("Program.Disassemble() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgram_Disassemble_CustomColors(t *testing.T) {
+	set, err := LoadInstructionSet(writeTestConfig(t, "[Colors]\nSUM=0a0a0a\n"))
+	if err != nil {
+		t.Fatalf("LoadInstructionSet() error = %v", err)
+	}
+	img := newTestImage(2, 1, &Pixel{R: 10, G: 10, B: 10}, OPERATIONS["SUM"])
+	tests := []struct {
+		name string
+		set  *InstructionSet
+		want string
+	}{
+		{name: "Default colors", set: NewInstructionSet(), want: "PUSH 30,SUM"},
+		{name: "Custom colors", set: set, want: "SUM,PUSH 415"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, err := Compile(img, tt.set, 1)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			var mnemonics []string
+			for index := 0; index < program.Len(); index++ {
+				mnemonics = append(mnemonics, program.Instruction(index).String())
+			}
+			if got := strings.Join(mnemonics, ","); got != tt.want {
+				t.Errorf("Instruction.String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadProgram(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		wantLen int
+		wantErr error
+	}{
+		{name: "Valid image", path: filepath.Join("..", "examples", "sum_from_image.png"), wantLen: 4},
+		{name: "Uppercase extension", path: filepath.Join("..", "examples", "sum.PNG"), wantLen: 4},
+		{name: "Not an image", path: filepath.Join("..", "examples", "configs.ini"), wantErr: ErrorDecodeImage},
+		{name: "Missing image", path: filepath.Join("..", "examples", "missing.png"), wantErr: ErrorOpenImage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, err := LoadProgram(tt.path, NewInstructionSet(), 1)
+			if err != tt.wantErr {
+				t.Fatalf("LoadProgram() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && program.Len() != tt.wantLen {
+				t.Errorf("LoadProgram() len = %d, want %d", program.Len(), tt.wantLen)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package interpreter
+
+/*
+ * Decoders of the image formats accepted by the interpreter.
+ * Formats are recognized by image.Decode from the content of the file, not from its extension.
+ * Lossy formats like JPEG alter the color codes, so their programs need a color tolerance (see InstructionSet.WithTolerance).
+ */
+import (
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
@@ -0,0 +1,114 @@
+package interpreter
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+// Encodes img as GIF using a palette with exactly its colors, so that no color is changed
+func encodeExactGIF(w io.Writer, img image.Image) error {
+	var palette color.Palette
+	seen := map[color.Color]bool{}
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if c := img.At(x, y); !seen[c] {
+				seen[c] = true
+				palette = append(palette, c)
+			}
+		}
+	}
+	paletted := image.NewPaletted(bounds, palette)
+	draw.Draw(paletted, bounds, img, bounds.Min, draw.Src)
+	return gif.Encode(w, paletted, nil)
+}
+
+func TestInterpreter_LoadImageFromReader(t *testing.T) {
+	// Prints "hi"
+	img, err := Assemble(strings.NewReader("push 0 push 105 push 104 output_ascii"), NewInstructionSet(), 2)
+	if err != nil {
+		t.Fatalf("Assemble() error = %v", err)
+	}
+	webpImage, err := os.ReadFile(filepath.Join("..", "examples", "tests", "hi.webp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name            string
+		encode          func(w io.Writer, img image.Image) error
+		instructionSize int
+		wantErr         error
+	}{
+		{name: "PNG", encode: png.Encode, instructionSize: 2},
+		{name: "GIF", encode: encodeExactGIF, instructionSize: 2},
+		{name: "BMP", encode: bmp.Encode, instructionSize: 2},
+		{name: "TIFF", encode: func(w io.Writer, img image.Image) error { return tiff.Encode(w, img, nil) }, instructionSize: 2},
+		{
+			name: "WebP",
+			encode: func(w io.Writer, _ image.Image) error {
+				_, err := w.Write(webpImage)
+				return err
+			},
+			instructionSize: 1,
+		},
+		{
+			name: "Unknown format",
+			encode: func(w io.Writer, _ image.Image) error {
+				_, err := io.WriteString(w, "[Colors]\nSUM=fff\n")
+				return err
+			},
+			instructionSize: 1,
+			wantErr:         ErrorDecodeImage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := &bytes.Buffer{}
+			if err := tt.encode(encoded, img); err != nil {
+				t.Fatalf("encode error = %v", err)
+			}
+			output := &bytes.Buffer{}
+			i, _ := NewInterpreterWithOptions(WithOutput(output), WithInstructionSize(tt.instructionSize))
+			if err := i.LoadImageFromReader(encoded); err != tt.wantErr {
+				t.Fatalf("Interpreter.LoadImageFromReader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if _, err := i.Run(); err != nil {
+				t.Fatalf("Interpreter.Run() error = %v", err)
+			}
+			if got := output.String(); got != "hi" {
+				t.Errorf("Interpreter.Run() output = %q, want \"hi\"", got)
+			}
+		})
+	}
+}
+
+func TestInterpreter_LoadImage_ContentSniffing(t *testing.T) {
+	// A PNG image saved with the wrong extension
+	content, err := os.ReadFile(filepath.Join("..", "examples", "sum_from_image.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "sum_from_image.gif")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	i, _ := NewInterpreterWithOptions()
+	if err := i.LoadImage(path); err != nil {
+		t.Errorf("Interpreter.LoadImage() error = %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Number of cells of the heap when no other size is set with WithHeapSize
+const DefaultHeapSize = 1024
+
+var (
+	ErrorHeapAddress     = errors.New("error: heap address out of bounds")
+	ErrorInvalidHeapSize = errors.New("error: heap size can't be negative")
+)
+
+// Returns the index of the heap cell at the given address, which must be an integer between 0 and the heap size excluded
+func heapIndex(i *Interpreter, address Value) (int, error) {
+	if address.Type() != ValueInt {
+		return 0, ErrorValueType
+	}
+	n, ok := address.Int64()
+	if !ok || n < 0 || n >= int64(len(i.heap)) {
+		return 0, ErrorHeapAddress
+	}
+	return int(n), nil
+}
+
+// Pops an address and a value, and writes the value in the heap cell at that address
+func store(i *Interpreter) (string, error) {
+	address, val, err := popTwoOrErr(i)
+	if err != nil {
+		return "", err
+	}
+	index, err := heapIndex(i, address)
+	if err != nil {
+		return "", err
+	}
+	i.heap[index] = val
+	if i.isDebug {
+		return "Popped " + address.String() + ", popped " + val.String() + " and then stored it in the heap at that address", nil
+	}
+	return "", nil
+}
+
+// Pops an address and pushes the value of the heap cell at that address
+func load(i *Interpreter) (string, error) {
+	address, err := popOrErr(i)
+	if err != nil {
+		return "", err
+	}
+	index, err := heapIndex(i, address)
+	if err != nil {
+		return "", err
+	}
+	val := i.heap[index]
+	if err := pushOrErr(i, val); err != nil {
+		return "", err
+	}
+	if i.isDebug {
+		return "Popped " + address.String() + " and then pushed the value stored in the heap at that address (" + val.String() + ")", nil
+	}
+	return "", nil
+}
+
+// Prints the address, the value and the type of the heap cells that are not 0, the value of the cells never stored
+func printHeap(i *Interpreter) {
+	for index, val := range i.heap {
+		if val.Bool() || val.Type() != ValueInt {
+			fmt.Fprintf(i.debugOutput, "\n[%d] %s %s", index, val, val.Type())
+		}
+	}
+}
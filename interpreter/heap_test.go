@@ -0,0 +1,119 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestInterpreter_RunHeap(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		source  string
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "Store and load",
+			source: "push 42 push 3 store push 7 push 0 store push 3 load output_int push 0 load output_int",
+			want:   "427",
+		},
+		{
+			name:   "Cells never stored",
+			source: "push 9 load output_int",
+			want:   "0",
+		},
+		{
+			name:   "Overwritten cell",
+			source: "push 1 push 5 store push 2 push 5 store push 5 load output_int",
+			want:   "2",
+		},
+		{
+			name:   "Float value",
+			source: "float_literal 2.5 push 1 store push 1 load output_float",
+			want:   "2.5",
+		},
+		{
+			// Counts from 1 to 3 keeping the counter in the cell 0
+			name:   "Variable",
+			source: "push 1 push 0 store push 1 while pop push 0 load dup output_int push 1 sum dup push 0 store push 4 lt while_end",
+			want:   "123",
+		},
+		{
+			name:   "Last cell",
+			opts:   []Option{WithHeapSize(2)},
+			source: "push 8 push 1 store push 1 load output_int",
+			want:   "8",
+		},
+		{
+			name:    "Store out of bounds",
+			opts:    []Option{WithHeapSize(2)},
+			source:  "push 8 push 2 store",
+			wantErr: ErrorHeapAddress,
+		},
+		{
+			name:    "Load at a negative address",
+			source:  "literal -1 load",
+			wantErr: ErrorHeapAddress,
+		},
+		{
+			name:    "Load from an empty heap",
+			opts:    []Option{WithHeapSize(0)},
+			source:  "push 0 load",
+			wantErr: ErrorHeapAddress,
+		},
+		{
+			name:    "Float address",
+			source:  "push 1 float_literal 0 store",
+			wantErr: ErrorValueType,
+		},
+		{
+			name:    "Store without value",
+			source:  "push 0 store",
+			wantErr: ErrorPop,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := runTestSource(t, tt.source, tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Interpreter.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if out != tt.want {
+				t.Errorf("Interpreter.Run() output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+	if _, err := NewInterpreterWithOptions(WithHeapSize(-1)); err != ErrorInvalidHeapSize {
+		t.Errorf("WithHeapSize() error = %v, want %v", err, ErrorInvalidHeapSize)
+	}
+}
+
+func TestInterpreter_RunDebuggerHeap(t *testing.T) {
+	var out, debugOut bytes.Buffer
+	i, err := NewInterpreterWithOptions(
+		WithDebug(true),
+		WithInput(strings.NewReader("step 9\nprint heap\nc\n")),
+		WithOutput(&out),
+		WithDebugOutput(&debugOut),
+	)
+	if err != nil {
+		t.Fatalf("NewInterpreterWithOptions() error = %v", err)
+	}
+	img, err := Assemble(strings.NewReader("push 4 push 2 store float_literal 0.5 push 7 store push 0 push 3 store nop"), i.instructions, 1)
+	if err != nil {
+		t.Fatalf("Assemble() error = %v", err)
+	}
+	if i.program, err = Compile(img, i.instructions, 1); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if _, err := i.Run(); err != nil {
+		t.Fatalf("Interpreter.Run() error = %v", err)
+	}
+	want := "(vilmos) \n[2] 4 int\n[7] 0.5 float\n(vilmos) "
+	if !strings.Contains(debugOut.String(), want) {
+		t.Errorf("debug output doesn't contain %q:\n%s", want, debugOut.String())
+	}
+}
@@ -0,0 +1,204 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/ini.v1"
+)
+
+var ErrorDuplicateColor = errors.New("error: the same color code is used by more than one operation")
+
+// Key of the config files setting the background color, which is not an operation
+const backgroundKey = "BACKGROUND"
+
+/*
+ * Color codes of the operations understood by an interpreter.
+ * An instruction set is never modified after its creation, so it can be shared by interpreters running in parallel.
+ * tolerance is the max distance, measured with metric, between a pixel and the color code of its operation.
+ * points holds the color codes in the color space of the metric.
+ * background, if not nil, is a color decoded as NOP like the color code of NOP.
+ */
+type InstructionSet struct {
+	colors       map[string]Pixel
+	operations   map[Pixel]string
+	metric       ColorMetric
+	tolerance    float64
+	points       map[string]colorPoint
+	transparency Transparency
+	background   *Pixel
+}
+
+// Returns an instruction set with the default color codes defined in OPERATIONS
+func NewInstructionSet() *InstructionSet {
+	set, _ := newInstructionSet(nil)
+	return set
+}
+
+/*
+ * Loads an instruction set from the given config file. Operations not specified in the file keep their default color code.
+ * The optional BACKGROUND key sets the background color of the set.
+ */
+func LoadInstructionSet(path string) (*InstructionSet, error) {
+	cfg, err := ini.Load(path)
+	if err != nil {
+		return nil, ErrorLoadConfig
+	}
+	custom := make(map[string]Pixel)
+	for op := range OPERATIONS {
+		value := cfg.Section("Colors").Key(op).String()
+		if len(value) != 0 {
+			newPx, err := hexToPixel(value)
+			if err != nil {
+				return nil, ErrorInvalidHex
+			}
+			custom[op] = *newPx
+		}
+	}
+	set, err := newInstructionSet(custom)
+	if err != nil {
+		return nil, err
+	}
+	if value := cfg.Section("Colors").Key(backgroundKey).String(); len(value) != 0 {
+		background, err := hexToPixel(value)
+		if err != nil {
+			return nil, ErrorInvalidHex
+		}
+		return set.WithBackground(*background)
+	}
+	return set, nil
+}
+
+/*
+ * Loads configs from the given config file and overrides standard operations color codes with the custom ones.
+ * The interpreters created afterwards with the default instruction set use the new color codes.
+ *
+ * Deprecated: changing OPERATIONS affects every interpreter of the process. Use LoadInstructionSet and WithInstructionSet instead.
+ */
+func LoadConfigs(path string) error {
+	set, err := LoadInstructionSet(path)
+	if err != nil {
+		return err
+	}
+	for op, px := range set.colors {
+		px := px
+		OPERATIONS[op] = &px
+	}
+	return nil
+}
+
+// Builds an instruction set overriding the default color codes with the custom ones
+func newInstructionSet(custom map[string]Pixel) (*InstructionSet, error) {
+	set := &InstructionSet{
+		colors:     make(map[string]Pixel, len(OPERATIONS)),
+		operations: make(map[Pixel]string, len(OPERATIONS)),
+	}
+	for op, px := range OPERATIONS {
+		set.colors[op] = *px
+	}
+	for op, px := range custom {
+		set.colors[op] = px
+	}
+	for op, px := range set.colors {
+		if _, ok := set.operations[px]; ok {
+			return nil, ErrorDuplicateColor
+		}
+		set.operations[px] = op
+	}
+	return set, nil
+}
+
+// Returns a copy of the instruction set where fully transparent instruction squares have the given meaning
+func (set *InstructionSet) WithTransparency(transparency Transparency) (*InstructionSet, error) {
+	if int(transparency) >= len(transparencyNames) {
+		return nil, ErrorInvalidTransparency
+	}
+	transparent := *set
+	transparent.transparency = transparency
+	return &transparent, nil
+}
+
+/*
+ * Returns a copy of the instruction set where the given color is decoded as NOP, so that the margins
+ * of a program can be painted with it. The color must not be the color code of an operation.
+ */
+func (set *InstructionSet) WithBackground(background Pixel) (*InstructionSet, error) {
+	if _, ok := set.operations[background]; ok {
+		return nil, ErrorDuplicateColor
+	}
+	withBackground := *set
+	withBackground.background = &background
+	return &withBackground, nil
+}
+
+// Returns the background color of the set. The second value is false if the set has no background color.
+func (set *InstructionSet) Background() (Pixel, bool) {
+	if set.background == nil {
+		return Pixel{}, false
+	}
+	return *set.background, true
+}
+
+// Returns the color code of the given operation
+func (set *InstructionSet) Color(op string) (Pixel, bool) {
+	px, ok := set.colors[op]
+	return px, ok
+}
+
+// Returns the name of the operation encoded by the given pixel. The second value is false if the pixel is not an operation.
+func (set *InstructionSet) Operation(p *Pixel) (string, bool) {
+	if set.background != nil && set.background.Equals(*p) {
+		return opcodeNames[OpNop], true
+	}
+	op, ok := set.operations[*p]
+	return op, ok
+}
+
+/*
+ * Decodes a pixel into an instruction. The background color is a NOP and every color not in the set
+ * pushes the sum of its red, green and blue values.
+ * With a tolerance, a pixel near to the color code of more than one operation returns a *CompileError
+ * without position listing the operations.
+ */
+func (set *InstructionSet) Decode(p *Pixel) (Instruction, error) {
+	if op, ok := set.Operation(p); ok {
+		return Instruction{Op: opcodesByName[op]}, nil
+	}
+	if set.tolerance > 0 {
+		ops := set.nearOperations(*p)
+		if len(ops) == 1 {
+			return Instruction{Op: opcodesByName[ops[0]]}, nil
+		}
+		if len(ops) > 1 {
+			return Instruction{}, &CompileError{Op: strings.Join(ops, " or "), Err: ErrorAmbiguousColor}
+		}
+	}
+	return Instruction{Op: OpPush, Value: int32(p.R) + int32(p.G) + int32(p.B)}, nil
+}
+
+// Converts a string representing an hex value to a Pixel structure. An error will be returned if the format is wrong.
+func hexToPixel(s string) (p *Pixel, err error) {
+	var r, g, b int32
+	switch len(s) {
+	case 6:
+		_, err = fmt.Sscanf(s, "%2x%2x%2x", &r, &g, &b)
+		if err != nil {
+			return nil, ErrorInvalidHex
+		}
+		return &Pixel{R: uint8(r), G: uint8(g), B: uint8(b)}, nil
+	case 3:
+		_, err = fmt.Sscanf(s, "%1x%1x%1x", &r, &g, &b)
+		if err != nil {
+			return nil, ErrorInvalidHex
+		}
+		// Double the hex digits:
+		r *= 17
+		g *= 17
+		b *= 17
+		return &Pixel{R: uint8(r), G: uint8(g), B: uint8(b)}, nil
+	default:
+		err = ErrorInvalidHex
+		return nil, err
+	}
+}
@@ -0,0 +1,222 @@
+package interpreter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "configs.ini")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("unable to write config: %v", err)
+	}
+	return path
+}
+
+func TestNewInstructionSet(t *testing.T) {
+	set := NewInstructionSet()
+	for op, px := range OPERATIONS {
+		got, ok := set.Color(op)
+		if !ok || !got.Equals(*px) {
+			t.Errorf("InstructionSet.Color(%s) = %v, want %v", op, got, px)
+		}
+		if name, ok := set.Operation(px); !ok || name != op {
+			t.Errorf("InstructionSet.Operation(%v) = %s, want %s", px, name, op)
+		}
+	}
+	if _, ok := set.Operation(&Pixel{R: 1, G: 2, B: 3}); ok {
+		t.Errorf("InstructionSet.Operation() found an operation for a value pixel")
+	}
+}
+
+func TestLoadInstructionSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    map[string]Pixel
+		wantErr error
+	}{
+		{
+			name: "Example configs",
+			path: filepath.Join("..", "examples", "configs.ini"),
+			want: map[string]Pixel{
+				"DUP":       {R: 255, G: 183, B: 50},
+				"SUM":       {R: 255, G: 203, B: 75},
+				"WHILE":     {R: 255, G: 163, B: 0},
+				"WHILE_END": {R: 255, G: 131, B: 75},
+				"SUB":       *OPERATIONS["SUB"],
+			},
+		},
+		{
+			name: "Short hex format",
+			path: writeTestConfig(t, "[Colors]\nPOP=123\n"),
+			want: map[string]Pixel{
+				"POP": {R: 17, G: 34, B: 51},
+			},
+		},
+		{
+			name:    "Invalid hex",
+			path:    writeTestConfig(t, "[Colors]\nPOP=zz3456\n"),
+			wantErr: ErrorInvalidHex,
+		},
+		{
+			name:    "Duplicated color",
+			path:    writeTestConfig(t, "[Colors]\nPOP=ffffff\n"),
+			wantErr: ErrorDuplicateColor,
+		},
+		{
+			name:    "Missing file",
+			path:    filepath.Join("..", "examples", "missing.ini"),
+			wantErr: ErrorLoadConfig,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := LoadInstructionSet(tt.path)
+			if err != tt.wantErr {
+				t.Fatalf("LoadInstructionSet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for op, want := range tt.want {
+				if got, _ := set.Color(op); !reflect.DeepEqual(got, want) {
+					t.Errorf("InstructionSet.Color(%s) = %v, want %v", op, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadInstructionSet_KeepsDefaults(t *testing.T) {
+	want := *OPERATIONS["DUP"]
+	if _, err := LoadInstructionSet(filepath.Join("..", "examples", "configs.ini")); err != nil {
+		t.Fatalf("LoadInstructionSet() error = %v", err)
+	}
+	if got := *OPERATIONS["DUP"]; got != want {
+		t.Errorf("OPERATIONS[DUP] = %v after loading configs, want %v", got, want)
+	}
+}
+
+func TestLoadConfigs(t *testing.T) {
+	defaults := make(map[string]*Pixel, len(OPERATIONS))
+	for op, px := range OPERATIONS {
+		defaults[op] = px
+	}
+	defer func() { OPERATIONS = defaults }()
+
+	if err := LoadConfigs(writeTestConfig(t, "[Colors]\nOUTPUT_INT=010101\n")); err != nil {
+		t.Fatalf("LoadConfigs() error = %v", err)
+	}
+	if got, want := *OPERATIONS["OUTPUT_INT"], (Pixel{R: 1, G: 1, B: 1}); got != want {
+		t.Errorf("OPERATIONS[OUTPUT_INT] = %v, want %v", got, want)
+	}
+	if got := *OPERATIONS["DUP"]; got != *defaults["DUP"] {
+		t.Errorf("OPERATIONS[DUP] = %v, want %v", got, *defaults["DUP"])
+	}
+	if op, ok := NewInstructionSet().Operation(&Pixel{R: 1, G: 1, B: 1}); !ok || op != "OUTPUT_INT" {
+		t.Errorf("NewInstructionSet().Operation() = %q, %v, want OUTPUT_INT", op, ok)
+	}
+	if err := LoadConfigs(writeTestConfig(t, "[Colors]\nDUP=zz\n")); err != ErrorInvalidHex {
+		t.Errorf("LoadConfigs() error = %v, want %v", err, ErrorInvalidHex)
+	}
+}
+
+func TestInstructionSet_Parallel(t *testing.T) {
+	custom, err := LoadInstructionSet(writeTestConfig(t, "[Colors]\nOUTPUT_INT=010101\n"))
+	if err != nil {
+		t.Fatalf("LoadInstructionSet() error = %v", err)
+	}
+	program := []*Pixel{{R: 5, G: 0, B: 0}, {R: 1, G: 1, B: 1}, OPERATIONS["OUTPUT_INT"]}
+	tests := []struct {
+		name string
+		set  *InstructionSet
+		want string
+	}{
+		{name: "Default palette", set: NewInstructionSet(), want: "3"},
+		{name: "Custom palette", set: custom, want: "5"},
+	}
+
+	var wg sync.WaitGroup
+	outputs := make([]*bytes.Buffer, len(tests))
+	for index, tt := range tests {
+		outputs[index] = &bytes.Buffer{}
+		i, err := NewInterpreterWithOptions(WithInstructionSet(tt.set), WithOutput(outputs[index]))
+		if err != nil {
+			t.Fatalf("NewInterpreterWithOptions() error = %v", err)
+		}
+		loadTestProgram(i, program...)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			i.Run()
+		}()
+	}
+	wg.Wait()
+	for index, tt := range tests {
+		if got := outputs[index].String(); got != tt.want {
+			t.Errorf("%s: output = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func Test_hexToPixel(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    *Pixel
+		wantErr bool
+	}{
+		{name: "Full hex", s: "a044d1", want: &Pixel{R: 160, G: 68, B: 209}},
+		{name: "Short hex", s: "fff", want: &Pixel{R: 255, G: 255, B: 255}},
+		{name: "Invalid length", s: "ffff", want: nil, wantErr: true},
+		{name: "Invalid digits", s: "gggggg", want: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hexToPixel(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("hexToPixel() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("hexToPixel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstructionSet_WithBackground(t *testing.T) {
+	background := Pixel{R: 10, G: 20, B: 30}
+	set, err := NewInstructionSet().WithBackground(background)
+	if err != nil {
+		t.Fatalf("InstructionSet.WithBackground() error = %v", err)
+	}
+	if got, err := set.Decode(&background); err != nil || got.Op != OpNop {
+		t.Errorf("InstructionSet.Decode(background) = %v, %v, want NOP", got, err)
+	}
+	if got, ok := set.Background(); !ok || got != background {
+		t.Errorf("InstructionSet.Background() = %v, %v, want %v", got, ok, background)
+	}
+	if _, ok := NewInstructionSet().Background(); ok {
+		t.Errorf("InstructionSet.Background() found a background in the default set")
+	}
+	if _, err := NewInstructionSet().WithBackground(*OPERATIONS["SUM"]); err != ErrorDuplicateColor {
+		t.Errorf("InstructionSet.WithBackground() error = %v, want %v", err, ErrorDuplicateColor)
+	}
+
+	loaded, err := LoadInstructionSet(writeTestConfig(t, "[Colors]\nNOP=010203\nBACKGROUND=0a141e\n"))
+	if err != nil {
+		t.Fatalf("LoadInstructionSet() error = %v", err)
+	}
+	for _, p := range []Pixel{{R: 1, G: 2, B: 3}, background} {
+		if got, err := loaded.Decode(&p); err != nil || got.Op != OpNop {
+			t.Errorf("InstructionSet.Decode(%v) = %v, %v, want NOP", &p, got, err)
+		}
+	}
+	if _, err := LoadInstructionSet(writeTestConfig(t, "[Colors]\nBACKGROUND=ffffff\n")); err != ErrorDuplicateColor {
+		t.Errorf("LoadInstructionSet() error = %v, want %v", err, ErrorDuplicateColor)
+	}
+}
@@ -2,25 +2,24 @@ package interpreter
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"image"
-	"image/png"
 	"io"
-	"log"
+	"math/big"
 	"math/rand"
 	"os"
 	"path/filepath"
 	"strconv"
 	"time"
-
-	"gopkg.in/ini.v1"
 )
 
 /*
  * Interpreter's throwable errors
  */
 var (
+	// Deprecated: images are recognized by their content, so their extension is no longer checked.
 	ErrorFileExtension    = errors.New("error: target image must be .png")
 	ErrorOpenImage        = errors.New("error: unable to open specified image")
 	ErrorRandomGenerator  = errors.New("error: trying to generate a random number with n <= 0")
@@ -37,473 +36,1128 @@ var (
 	ErrorFileAlreadyOpen  = errors.New("error: trying to open multiple files")
 	ErrorMissingStartLoop = errors.New("error: missing start loop")
 	ErrorMissingEndLoop   = errors.New("error: missing end loop")
+	ErrorMissingStartIf   = errors.New("error: missing start if")
+	ErrorMissingEndIf     = errors.New("error: missing end if")
+	ErrorDuplicateElse    = errors.New("error: more than one else in the same if")
 	ErrorNoSpaceString    = errors.New("error: not enough space in to stack to push the string")
+	ErrorNoOpenedFile     = errors.New("error: trying to close a file but none is open")
+	ErrorWriteOutput      = errors.New("error: unable to write the output")
+	ErrorStepLimit        = errors.New("error: max number of steps reached")
+	ErrorTimeout          = errors.New("error: execution timed out")
+	ErrorCanceled         = errors.New("error: execution canceled")
+	ErrorInvalidMaxSteps  = errors.New("error: max number of steps can't be negative")
+	ErrorInvalidTimeout   = errors.New("error: timeout can't be negative")
 )
 
+// Error returned when the execution of an instruction fails. It wraps the cause
+// together with the state of the interpreter at the moment of the failure.
+type RuntimeError struct {
+	Op        string
+	PC        image.Point
+	StackSize int
+	Module    string
+	Err       error
+}
+
+func (e *RuntimeError) Error() string {
+	if e.Module != "" {
+		return fmt.Sprintf("%s [op: %s, pc: (%d, %d), stack size: %d, module: %s]", e.Err.Error(), e.Op, e.PC.X, e.PC.Y, e.StackSize, e.Module)
+	}
+	return fmt.Sprintf("%s [op: %s, pc: (%d, %d), stack size: %d]", e.Err.Error(), e.Op, e.PC.X, e.PC.Y, e.StackSize)
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+// Error returned when the execution is stopped by the step limit, the timeout or the context.
+// It reports how many steps were executed and the position of the next instruction to execute.
+type LimitError struct {
+	Steps int
+	PC    image.Point
+	Err   error
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s [steps: %d, pc: (%d, %d)]", e.Err.Error(), e.Steps, e.PC.X, e.PC.Y)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
 /*
- * A map of all interpreter's operations
+ * A map of all interpreter's operations with their default color codes.
+ * It is never modified: custom color codes are loaded in an InstructionSet.
  */
 var OPERATIONS = map[string]*Pixel{
-	"INPUT_INT":    {R: 255, G: 255, B: 255}, //#ffffff -> INPUT INT
-	"OUTPUT_INT":   {R: 0, G: 0, B: 1},       //#000001 -> OUTPUT INT
-	"SUM":          {R: 0, G: 206, B: 209},   //#00ced1 -> SUM
-	"SUB":          {R: 255, G: 165, B: 0},   //#ffa500 -> SUBTRACTION
-	"DIV":          {R: 138, G: 43, B: 226},  //#8a2be2 -> DIVISION
-	"MUL":          {R: 139, G: 0, B: 0},     //#8b0000 -> MULTIPLICATION
-	"MOD":          {R: 255, G: 218, B: 185}, //#ffdab9 -> MODULUS
-	"RND":          {R: 0, G: 128, B: 0},     //#008000 -> RANDOM
-	"AND":          {R: 236, G: 243, B: 220}, //#ecf3dc -> AND
-	"OR":           {R: 183, G: 198, B: 230}, //#b7c6e6 -> OR
-	"XOR":          {R: 245, G: 227, B: 215}, //#f5e3d7 -> XOR
-	"NAND":         {R: 225, G: 211, B: 239}, //#e1d3ef -> NAND
-	"NOT":          {R: 255, G: 154, B: 162}, //#ff9aa2 -> NOT
-	"BAND":         {R: 138, G: 163, B: 153}, //#8aa399 -> BIT AND
-	"BOR":          {R: 125, G: 132, B: 178}, //#7d84b2 -> BIT OR
-	"BXOR":         {R: 143, G: 166, B: 203}, //#8fa6cb -> BIT XOR
-	"BNOT":         {R: 219, G: 244, B: 167}, //#dbf4a7 -> BIT NOT
-	"LSHIFT":       {R: 45, G: 106, B: 125},  //#2d6a7d -> LEFT SHIFT
-	"RSHIFT":       {R: 67, G: 157, B: 186},  //#439dba -> RIGHT SHIFT
-	"INPUT_ASCII":  {R: 227, G: 227, B: 227}, //#e3e3e3 -> INPUT ASCII
-	"OUTPUT_ASCII": {R: 75, G: 75, B: 75},    //#4b4b4b -> OUTPUT ASCII
-	"POP":          {R: 204, G: 158, B: 6},   //#cc9e06 -> POP
-	"SWAP":         {R: 255, G: 189, B: 74},  //#ffbd4a -> SWAP
-	"CYCLE":        {R: 227, G: 127, B: 157}, //#e37f9d -> CYCLE
-	"RCYCLE":       {R: 233, G: 148, B: 174}, //#e994ae -> RCYCLE
-	"DUP":          {R: 0, G: 105, B: 148},   //#006994 -> DUPLICATE
-	"REVERSE":      {R: 165, G: 165, B: 141}, //#a5a58d -> REVERSE
-	"QUIT":         {R: 183, G: 228, B: 199}, //#b7e4c7 -> QUIT PROGRAM
-	"OUTPUT":       {R: 155, G: 34, B: 66},   //#9B2242 -> OUTPUT ALL STACK
-	"WHILE":        {R: 46, G: 26, B: 71},    //#2e1a47 -> START WHILE LOOP
-	"WHILE_END":    {R: 104, G: 71, B: 141},  //#68478d -> END WHILE LOOP
-	"FILE_OPEN":    {R: 145, G: 246, B: 139}, //#91f68b -> OPEN FILE
-	"FILE_CLOSE":   {R: 47, G: 237, B: 35},   //#2fed23 -> CLOSE FILE
+	"INPUT_INT":     {R: 255, G: 255, B: 255}, //#ffffff -> INPUT INT
+	"OUTPUT_INT":    {R: 0, G: 0, B: 1},       //#000001 -> OUTPUT INT
+	"SUM":           {R: 0, G: 206, B: 209},   //#00ced1 -> SUM
+	"SUB":           {R: 255, G: 165, B: 0},   //#ffa500 -> SUBTRACTION
+	"DIV":           {R: 138, G: 43, B: 226},  //#8a2be2 -> DIVISION
+	"MUL":           {R: 139, G: 0, B: 0},     //#8b0000 -> MULTIPLICATION
+	"MOD":           {R: 255, G: 218, B: 185}, //#ffdab9 -> MODULUS
+	"RND":           {R: 0, G: 128, B: 0},     //#008000 -> RANDOM
+	"AND":           {R: 236, G: 243, B: 220}, //#ecf3dc -> AND
+	"OR":            {R: 183, G: 198, B: 230}, //#b7c6e6 -> OR
+	"XOR":           {R: 245, G: 227, B: 215}, //#f5e3d7 -> XOR
+	"NAND":          {R: 225, G: 211, B: 239}, //#e1d3ef -> NAND
+	"NOT":           {R: 255, G: 154, B: 162}, //#ff9aa2 -> NOT
+	"BAND":          {R: 138, G: 163, B: 153}, //#8aa399 -> BIT AND
+	"BOR":           {R: 125, G: 132, B: 178}, //#7d84b2 -> BIT OR
+	"BXOR":          {R: 143, G: 166, B: 203}, //#8fa6cb -> BIT XOR
+	"BNOT":          {R: 219, G: 244, B: 167}, //#dbf4a7 -> BIT NOT
+	"LSHIFT":        {R: 45, G: 106, B: 125},  //#2d6a7d -> LEFT SHIFT
+	"RSHIFT":        {R: 67, G: 157, B: 186},  //#439dba -> RIGHT SHIFT
+	"INPUT_ASCII":   {R: 227, G: 227, B: 227}, //#e3e3e3 -> INPUT ASCII
+	"OUTPUT_ASCII":  {R: 75, G: 75, B: 75},    //#4b4b4b -> OUTPUT ASCII
+	"POP":           {R: 204, G: 158, B: 6},   //#cc9e06 -> POP
+	"SWAP":          {R: 255, G: 189, B: 74},  //#ffbd4a -> SWAP
+	"CYCLE":         {R: 227, G: 127, B: 157}, //#e37f9d -> CYCLE
+	"RCYCLE":        {R: 233, G: 148, B: 174}, //#e994ae -> RCYCLE
+	"DUP":           {R: 0, G: 105, B: 148},   //#006994 -> DUPLICATE
+	"REVERSE":       {R: 165, G: 165, B: 141}, //#a5a58d -> REVERSE
+	"QUIT":          {R: 183, G: 228, B: 199}, //#b7e4c7 -> QUIT PROGRAM
+	"OUTPUT":        {R: 155, G: 34, B: 66},   //#9B2242 -> OUTPUT ALL STACK
+	"WHILE":         {R: 46, G: 26, B: 71},    //#2e1a47 -> START WHILE LOOP
+	"WHILE_END":     {R: 104, G: 71, B: 141},  //#68478d -> END WHILE LOOP
+	"FILE_OPEN":     {R: 145, G: 246, B: 139}, //#91f68b -> OPEN FILE
+	"FILE_CLOSE":    {R: 47, G: 237, B: 35},   //#2fed23 -> CLOSE FILE
+	"NOP":           {R: 230, G: 230, B: 250}, //#e6e6fa -> NO OPERATION
+	"TURN_RIGHT":    {R: 255, G: 127, B: 80},  //#ff7f50 -> TURN DIRECTION CLOCKWISE
+	"TURN_LEFT":     {R: 100, G: 149, B: 237}, //#6495ed -> TURN DIRECTION COUNTERCLOCKWISE
+	"REFLECT":       {R: 220, G: 20, B: 60},   //#dc143c -> REVERSE DIRECTION
+	"TURN_IF":       {R: 255, G: 215, B: 0},   //#ffd700 -> TURN DIRECTION CLOCKWISE IF TRUE
+	"EQ":            {R: 32, G: 178, B: 170},  //#20b2aa -> EQUAL
+	"NEQ":           {R: 255, G: 99, B: 71},   //#ff6347 -> NOT EQUAL
+	"GT":            {R: 154, G: 205, B: 50},  //#9acd32 -> GREATER THAN
+	"LT":            {R: 186, G: 85, B: 211},  //#ba55d3 -> LESS THAN
+	"GTE":           {R: 60, G: 179, B: 113},  //#3cb371 -> GREATER THAN OR EQUAL
+	"LTE":           {R: 218, G: 112, B: 214}, //#da70d6 -> LESS THAN OR EQUAL
+	"IF":            {R: 70, G: 130, B: 180},  //#4682b4 -> START IF BLOCK
+	"ELSE":          {R: 95, G: 158, B: 160},  //#5f9ea0 -> START ELSE BLOCK
+	"END_IF":        {R: 25, G: 25, B: 112},   //#191970 -> END IF BLOCK
+	"LABEL":         {R: 139, G: 69, B: 19},   //#8b4513 -> START ROUTINE
+	"CALL":          {R: 47, G: 79, B: 79},    //#2f4f4f -> CALL ROUTINE
+	"RETURN":        {R: 112, G: 128, B: 144}, //#708090 -> RETURN FROM ROUTINE
+	"IMPORT":        {R: 210, G: 105, B: 30},  //#d2691e -> IMPORT MODULE
+	"OVER":          {R: 255, G: 140, B: 0},   //#ff8c00 -> COPY SECOND ITEM
+	"ROT":           {R: 143, G: 188, B: 143}, //#8fbc8f -> ROTATE TOP THREE ITEMS
+	"PICK":          {R: 219, G: 112, B: 147}, //#db7093 -> COPY N-TH ITEM
+	"ROLL":          {R: 184, G: 134, B: 11},  //#b8860b -> MOVE N-TH ITEM
+	"DEPTH":         {R: 0, G: 191, B: 255},   //#00bfff -> STACK SIZE
+	"CLEAR":         {R: 240, G: 230, B: 140}, //#f0e68c -> CLEAR STACK
+	"LITERAL":       {R: 127, G: 255, B: 212}, //#7fffd4 -> PUSH THE 24 BITS VALUE OF THE NEXT PIXEL
+	"FLOAT_LITERAL": {R: 0, G: 255, B: 127},   //#00ff7f -> PUSH THE FLOAT OF THE NEXT TWO PIXELS
+	"INT_TO_FLOAT":  {R: 65, G: 105, B: 225},  //#4169e1 -> CONVERT INTEGER TO FLOAT
+	"FLOAT_TO_INT":  {R: 85, G: 107, B: 47},   //#556b2f -> CONVERT FLOAT TO INTEGER
+	"FLOAT_SUM":     {R: 255, G: 20, B: 147},  //#ff1493 -> FLOAT SUM
+	"FLOAT_SUB":     {R: 255, G: 69, B: 0},    //#ff4500 -> FLOAT SUBTRACTION
+	"FLOAT_MUL":     {R: 128, G: 0, B: 128},   //#800080 -> FLOAT MULTIPLICATION
+	"FLOAT_DIV":     {R: 0, G: 0, B: 139},     //#00008b -> FLOAT DIVISION
+	"INPUT_FLOAT":   {R: 173, G: 255, B: 47},  //#adff2f -> INPUT FLOAT
+	"OUTPUT_FLOAT":  {R: 128, G: 128, B: 0},   //#808000 -> OUTPUT FLOAT
+	"STORE":         {R: 205, G: 92, B: 92},   //#cd5c5c -> STORE IN THE HEAP
+	"LOAD":          {R: 244, G: 164, B: 96},  //#f4a460 -> LOAD FROM THE HEAP
 }
 
 // Interpreter structure
 type Interpreter struct {
 	image           image.Image
+	program         *Program
 	stack           *Stack
-	pc              image.Point
+	pc              int
 	width           int
 	height          int
 	isDebug         bool
 	instructionSize int
 	openedFile      *os.File
+	instructions    *InstructionSet
+	jumped          bool
+	halted          bool
+	exitCode        int
+	input           *bufio.Reader
+	output          io.Writer
+	debugOutput     io.Writer
+	maxSteps        int
+	timeout         time.Duration
+	fileRoot        string
+	fileAccess      FileAccess
+	region          Region
+	mode            ExecutionMode
+	direction       Direction
+	callStack       []callFrame
+	maxCallDepth    int
+	main            *Program
+	modules         map[string]*module
+	routines        map[int32]routine
+	strict          bool
+	heap            []Value
 }
 
 // Interpreter's constructor. Params are flags value from CLI app.
-func NewInterpreter(debug bool, maxSize int, instructionSize int) *Interpreter {
+func NewInterpreter(debug bool, maxSize int, instructionSize int) (*Interpreter, error) {
+	return NewInterpreterWithOptions(
+		WithDebug(debug),
+		WithMaxSize(maxSize),
+		WithInstructionSize(instructionSize),
+	)
+}
+
+/*
+ * Interpreter's constructor configured by options.
+ * By default the stack has no max size, each instruction is a single pixel, operations use the default color codes
+ * and the interpreter reads from stdin and writes both output and debug messages to stdout.
+ */
+func NewInterpreterWithOptions(opts ...Option) (*Interpreter, error) {
 	rand.Seed(time.Now().UnixNano())
 
-	stack, err := NewStack(maxSize)
-	checkError(err, ErrorInvalidMaxSize)
+	stack, err := NewStack(-1)
+	if err != nil {
+		return nil, err
+	}
 
 	interpreter := &Interpreter{
 		image:           nil,
+		program:         nil,
 		stack:           stack,
-		pc:              image.Point{X: 0, Y: 0},
+		pc:              0,
 		width:           0,
 		height:          0,
-		isDebug:         debug,
-		instructionSize: instructionSize,
+		isDebug:         false,
+		instructionSize: 1,
 		openedFile:      nil,
+		instructions:    NewInstructionSet(),
+		input:           bufio.NewReader(os.Stdin),
+		output:          os.Stdout,
+		debugOutput:     os.Stdout,
+		maxCallDepth:    DefaultMaxCallDepth,
+		heap:            make([]Value, DefaultHeapSize),
+	}
+	for _, opt := range opts {
+		if err := opt(interpreter); err != nil {
+			return nil, err
+		}
 	}
-	image.RegisterFormat("png", "png", png.Decode, png.DecodeConfig)
 
-	return interpreter
+	return interpreter, nil
 }
 
-// Loads image from OS and puts the stream into the interpreter image reference
+/*
+ * Loads image from OS, puts the stream into the interpreter image reference and compiles it into the program to run.
+ * Modules imported by the program are resolved from the directory of the image.
+ */
 func (i *Interpreter) LoadImage(path string) error {
-	fileExtension := filepath.Ext(path)
-
-	if fileExtension != ".png" {
-		return ErrorFileExtension
-	}
-
 	f, err := os.Open(path)
 	if err != nil {
 		return ErrorOpenImage
 	}
 	defer f.Close()
-	img, _, err := image.Decode(f)
+	if err := i.LoadImageFromReader(f); err != nil {
+		return err
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+			abs = resolved
+		}
+		i.program.path = abs
+		i.modules[abs] = &module{program: i.program} // the main program can't be imported by its modules
+	}
+	return nil
+}
+
+/*
+ * Decodes the image read from r, whatever its supported format, and compiles it into the program to run.
+ * Only the region of the interpreter is compiled, the whole image by default.
+ */
+func (i *Interpreter) LoadImageFromReader(r io.Reader) error {
+	img, _, err := image.Decode(r)
 	if err != nil {
 		return ErrorDecodeImage
 	}
+	if i.region != (Region{}) {
+		img, err = Crop(img, i.region)
+		if err != nil {
+			return err
+		}
+	}
+	program, err := Compile(img, i.instructions, i.instructionSize)
+	if err != nil {
+		return err
+	}
 	i.image = img
+	i.program = program
+	i.pc = 0
+	i.direction = DirectionRight
+	i.jumped, i.halted, i.exitCode = false, false, 0 // a previous program could have stopped with QUIT
+	i.callStack = nil
+	i.main = program
+	i.modules = make(map[string]*module)
+	i.routines = nil
+	if err := addRoutines(i, program); err != nil {
+		return err
+	}
 	i.width, i.height = i.image.Bounds().Max.X, i.image.Bounds().Max.Y
 	return nil
 }
 
+// Loads the image at the given path and compiles it into a program without creating an interpreter
+func LoadProgram(path string, set *InstructionSet, instructionSize int) (*Program, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, ErrorOpenImage
+	}
+	defer f.Close()
+	return LoadProgramFromReader(f, set, instructionSize)
+}
+
+// Decodes the image read from r and compiles it into a program without creating an interpreter
+func LoadProgramFromReader(r io.Reader, set *InstructionSet, instructionSize int) (*Program, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, ErrorDecodeImage
+	}
+	return Compile(img, set, instructionSize)
+}
+
+// Executes the image interpretation without a context. See RunContext.
+func (i *Interpreter) Run() (int, error) {
+	return i.RunContext(context.Background())
+}
+
 /*
  * Executes the image interpretation doing Step() while the image program is terminated.
- * It is responsible to increase the program counter and calling the debugger if the flag is set.
+ * It is responsible to increase the program counter and calling the debugger if the flag is set:
+ * the debugger stops before the first instruction and then as requested by the user commands.
+ * Returns the exit code of the program and the error that stopped it, if any.
+ * Reaching the end of the image, a QUIT instruction or the quit debugger command are normal terminations.
+ * The execution is stopped with a *LimitError when the max number of steps is reached, when the timeout expires
+ * or when ctx is done. Limits are checked between instructions, so an instruction waiting for input is not interrupted.
  */
-func (i *Interpreter) Run() error {
-	err := error(nil)
+func (i *Interpreter) RunContext(ctx context.Context) (int, error) {
+	if i.program == nil {
+		return 2, ErrorNoProgram
+	}
+	if i.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.timeout)
+		defer cancel()
+	}
+	done := ctx.Done()
+	var dbg *debugger
+	if i.isDebug {
+		dbg = &debugger{}
+	}
 	stepCount := 0
-	for err == nil {
-		_, msg := i.Step()
+	msg := "Program loaded"
+	for {
+		if i.maxSteps > 0 && stepCount >= i.maxSteps {
+			return 2, &LimitError{Steps: stepCount, PC: i.program.Position(i.pc), Err: ErrorStepLimit}
+		}
+		if done != nil {
+			select {
+			case <-done:
+				err := ErrorCanceled
+				if ctx.Err() == context.DeadlineExceeded {
+					err = ErrorTimeout
+				}
+				return 2, &LimitError{Steps: stepCount, PC: i.program.Position(i.pc), Err: err}
+			default:
+			}
+		}
+		if dbg != nil && dbg.shouldStop(i) {
+			if quit := dbg.prompt(i, stepCount, msg); quit {
+				return 0, nil
+			}
+		}
+		running, message, err := i.Step()
+		if err != nil {
+			return 2, err
+		}
 		stepCount++
-		if i.isDebug {
-			debug(i, stepCount, msg)
-			_, e := fmt.Scanf("\n")
-			if e != nil {
-				return ErrorInputScanning
+		msg = message
+		if !running {
+			return i.exitCode, nil
+		}
+		if i.jumped {
+			i.jumped = false
+			continue
+		}
+		// The end of a module returns to the IMPORT or to the CALL that started its execution
+		for i.increasePC() == ErrorOutOfBounds {
+			if !inModule(i) {
+				return 0, nil
+			}
+			if _, err := ret(i); err != nil {
+				return 0, nil
 			}
 		}
-		err = i.increasePC()
 	}
-	return err
 }
 
-// Interprets and executes next pixel in the given image
-func (i *Interpreter) Step() (bool, string) {
-	px := i.readPixel()
-	msg := processPixel(px, i)
-	return true, msg
+/*
+ * Interprets and executes the instruction pointed by the program counter.
+ * Returns false if the program asked to terminate, the debug message and a *RuntimeError if the instruction failed.
+ */
+func (i *Interpreter) Step() (bool, string, error) {
+	if i.program == nil {
+		return false, "", ErrorNoProgram
+	}
+	ins := i.program.code[i.pc]
+	program, pc, stackSize := i.program, i.pc, i.stack.Size()
+	module := ""
+	if inModule(i) {
+		module = program.path
+	}
+	msg, err := processInstruction(ins, i)
+	if err != nil {
+		return false, msg, &RuntimeError{Op: ins.Op.String(), PC: program.Position(pc), StackSize: stackSize, Module: module, Err: err}
+	}
+	return !i.halted, msg, nil
+}
+
+// Reads pixel at the given coordinates and returns a Pixel struct reference. Alpha is ignored, see readColor.
+func readPixel(img image.Image, x int, y int) *Pixel {
+	p, _ := readColor(img, x, y)
+	return p
 }
 
-// Reads pixel pointed by program counter and returns a Pixel struct reference
-func (i *Interpreter) readPixel() *Pixel {
-	return rgbaToPixel(i.image.At(i.pc.X, i.pc.Y).RGBA())
+// Returns the arithmetic of the stack numbers, strict if the interpreter checks overflows
+func (i *Interpreter) arithmetic() arithmetic {
+	return arithmetic{numbers: i.stack.numbers, strict: i.strict}
 }
 
-// Creates and returns a Pixel structure reference by rgba values
-func rgbaToPixel(r uint32, g uint32, b uint32, _ uint32) *Pixel {
-	return &Pixel{R: uint8(r / 257), G: uint8(g / 257), B: uint8(b / 257)}
+// Tries to pop the stack. If it fails, the stack error is returned
+func popOrErr(i *Interpreter) (Value, error) {
+	return i.stack.Pop()
 }
 
-// Tries to pop the stack. If it fails, an error will the throwed
-func popOrErr(i *Interpreter) int32 {
-	val, err := i.stack.Pop()
-	checkError(err, err)
-	return val
+// Tries to pop the two topmost items of the stack. The first value returned is the top of the stack.
+func popTwoOrErr(i *Interpreter) (Value, Value, error) {
+	v1, err := popOrErr(i)
+	if err != nil {
+		return Value{}, Value{}, err
+	}
+	v2, err := popOrErr(i)
+	if err != nil {
+		return Value{}, Value{}, err
+	}
+	return v1, v2, nil
 }
 
-// Tries to push an item in the stack. If it fails, an error will be throwed.
-func pushOrErr(i *Interpreter, val int32) {
-	err := i.stack.Push(val)
-	checkError(err, err)
+// Tries to push an item in the stack. If it fails, the stack error is returned
+func pushOrErr(i *Interpreter, val Value) error {
+	return i.stack.Push(val)
 }
 
-// Tries to read input from a given format. If it fails, an error will be throwed.
-func scanfOrErr(format string, a *int32) {
-	_, err := fmt.Scanf(format, a)
-	checkError(err, ErrorInputScanning)
+// Tries to read input from a given format. If it fails, ErrorInputScanning is returned
+func scanfOrErr(i *Interpreter, format string, a interface{}) error {
+	_, err := fmt.Fscanf(i.input, format, a)
+	if err != nil {
+		return ErrorInputScanning
+	}
+	return nil
 }
 
-// Executes a given pixel. Returns a message for the debugging.
-func processPixel(pixel *Pixel, i *Interpreter) string {
-	switch pixel.String() {
-	case OPERATIONS["INPUT_INT"].String(): //Gets value from input as number and pushes it to the stack
-		var val int32
+// Executes a given instruction. Returns a message for the debugging or the error that stopped the execution.
+func processInstruction(ins Instruction, i *Interpreter) (string, error) {
+	switch ins.Op {
+	case OpInputInt: //Gets value from input as number and pushes it to the stack
+		var val Value
 		if hasOpenedFile(i) {
 
 			content, err := readFromFile(i)
-			checkError(err, err)
+			if err != nil {
+				return "", err
+			}
 			if i.isDebug {
-				return "Pushed " + truncateString(content, 50) + " into the stack"
+				return "Pushed " + truncateString(content, 50) + " into the stack", nil
 			}
 
 		} else {
-			scanfOrErr("%d\n", &val)
-			pushOrErr(i, val)
+			n := new(big.Int)
+			if err := scanfOrErr(i, "%d\n", n); err != nil {
+				return "", err
+			}
+			// Numbers out of the range of the stack can't be read, as for the numbers too big to be scanned
+			if val = bigValue(n); !i.stack.numbers.holds(val) {
+				return "", ErrorInputScanning
+			}
+			if err := pushOrErr(i, val); err != nil {
+				return "", err
+			}
 		}
 		if i.isDebug {
-			return "Pushed " + int32ToString(val) + " into the stack"
+			return "Pushed " + val.String() + " into the stack", nil
 		}
-	case OPERATIONS["INPUT_ASCII"].String(): //Gets values as ASCII char of a string and puts them into the stack
+	case OpInputASCII: //Gets values as ASCII char of a string and puts them into the stack
 		var val string
 		if hasOpenedFile(i) {
 
 			content, err := readFromFile(i)
-			checkError(err, err)
+			if err != nil {
+				return "", err
+			}
 			if i.isDebug {
-				return "Pushed " + truncateString(content, 50) + " into the stack"
+				return "Pushed " + truncateString(content, 50) + " into the stack", nil
 			}
 
 		} else {
-			_, err := fmt.Scanf("%s\n", &val)
-			checkError(err, ErrorInputScanning)
+			if err := scanfOrErr(i, "%s\n", &val); err != nil {
+				return "", err
+			}
 
-			if isEnoughSpaceForString(i, val) {
-				pushOrErr(i, int32('\000'))
-				for _, char := range val {
-					pushOrErr(i, int32(char))
+			if !isEnoughSpaceForString(i, val) {
+				return "", ErrorNoSpaceString
+			}
+			if err := pushOrErr(i, IntValue('\000')); err != nil {
+				return "", err
+			}
+			for _, char := range val {
+				if err := pushOrErr(i, IntValue(int64(char))); err != nil {
+					return "", err
 				}
-			} else {
-				logError(ErrorNoSpaceString)
 			}
-
 		}
 
 		if i.isDebug {
-			return "Pushed " + val + " into the stack"
-		}
-	case OPERATIONS["OUTPUT_INT"].String(): //Pops the top of the stack and outputs it as number
-		val := popOrErr(i)
-		if hasOpenedFile(i) {
-			_, err := i.openedFile.WriteString(strconv.Itoa(int(val)))
-			checkError(err, ErrorWriteFile)
-			return "Wrote " + int32ToString(val) + " to the opened file (" + i.openedFile.Name() + ")"
-		} else {
-			fmt.Printf("%d", val)
+			return "Pushed " + val + " into the stack", nil
 		}
-		if i.isDebug {
-			return "Popped " + int32ToString(val) + " from the stack and printed it in the console"
+	case OpOutputInt: //Pops the top of the stack and outputs it as number
+		return outputNumber(i, ValueInt)
+	case OpOutputASCII: //Pops the top of the stack and outputs it as ASCII char
+		if hasOpenedFile(i) && i.fileAccess == FileAccessReadOnly {
+			return "", ErrorFileReadOnly
 		}
-	case OPERATIONS["OUTPUT_ASCII"].String(): //Pops the top of the stack and outputs it as ASCII char
 		str, err := buildStringFromStack(i)
-		checkError(err, ErrorInvalidString)
+		if err != nil {
+			return "", err
+		}
 		if hasOpenedFile(i) {
 			_, err := i.openedFile.WriteString(str)
-			checkError(err, ErrorWriteFile)
-			return "Wrote " + str + " to the opened file (" + i.openedFile.Name() + ")"
+			if err != nil {
+				return "", ErrorWriteFile
+			}
+			return "Wrote " + str + " to the opened file (" + i.openedFile.Name() + ")", nil
 		} else {
-			fmt.Printf("%s", str)
+			if _, err := fmt.Fprintf(i.output, "%s", str); err != nil {
+				return "", ErrorWriteOutput
+			}
 		}
 		if i.isDebug {
-			return "Popped " + str + " from the stack and printed it in the console"
+			return "Popped " + str + " from the stack and printed it in the console", nil
+		}
+	case OpSum: //Pops two numbers, adds them and pushes the result in the stack
+		v1, v2, err := popTwoOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		sum, err := i.arithmetic().add(v1, v2)
+		if err != nil {
+			return "", err
+		}
+		if err := pushOrErr(i, sum); err != nil {
+			return "", err
 		}
-	case OPERATIONS["SUM"].String(): //Pops two numbers, adds them and pushes the result in the stack
-		v1 := popOrErr(i)
-		v2 := popOrErr(i)
-		sum := v1 + v2
-		pushOrErr(i, sum)
 		if i.isDebug {
-			return "Popped " + int32ToString(v1) + ", popped " + int32ToString(v2) + " and then pushed into the stack their sum (" + int32ToString(sum) + ")"
+			return "Popped " + v1.String() + ", popped " + v2.String() + " and then pushed into the stack their sum (" + sum.String() + ")", nil
+		}
+	case OpSub: //Pops two numbers, subtracts them and pushes the result in the stack
+		v1, v2, err := popTwoOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		sub, err := i.arithmetic().sub(v2, v1)
+		if err != nil {
+			return "", err
+		}
+		if err := pushOrErr(i, sub); err != nil {
+			return "", err
 		}
-	case OPERATIONS["SUB"].String(): //Pops two numbers, subtracts them and pushes the result in the stack
-		v1 := popOrErr(i)
-		v2 := popOrErr(i)
-		sub := v2 - v1
-		pushOrErr(i, sub)
 		if i.isDebug {
-			return "Popped " + int32ToString(v1) + ", popped " + int32ToString(v2) + " and then pushed into the stack their difference (" + int32ToString(sub) + ")"
+			return "Popped " + v1.String() + ", popped " + v2.String() + " and then pushed into the stack their difference (" + sub.String() + ")", nil
+		}
+	case OpDiv: //Pops two numbers, divides them and pushes the result in the stack
+		v1, v2, err := popTwoOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		div, err := i.arithmetic().quo(v2, v1)
+		if err != nil {
+			return "", err
+		}
+		if err := pushOrErr(i, div); err != nil {
+			return "", err
 		}
-	case OPERATIONS["DIV"].String(): //Pops two numbers, divides them and pushes the result in the stack
-		v1 := popOrErr(i)
-		v2 := popOrErr(i)
-		div := v2 / v1
-		pushOrErr(i, div)
 		if i.isDebug {
-			return "Popped " + int32ToString(v1) + ", popped " + int32ToString(v2) + " and then pushed into the stack the result of their division (" + int32ToString(div) + ")"
+			return "Popped " + v1.String() + ", popped " + v2.String() + " and then pushed into the stack the result of their division (" + div.String() + ")", nil
+		}
+	case OpMul: //Pops two numbers, multiplies them and pushes the result in the stack
+		v1, v2, err := popTwoOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		mul, err := i.arithmetic().mul(v1, v2)
+		if err != nil {
+			return "", err
+		}
+		if err := pushOrErr(i, mul); err != nil {
+			return "", err
 		}
-	case OPERATIONS["MUL"].String(): //Pops two numbers, multiplies them and pushes the result in the stack
-		v1 := popOrErr(i)
-		v2 := popOrErr(i)
-		mul := v1 * v2
-		pushOrErr(i, mul)
 		if i.isDebug {
-			return "Popped " + int32ToString(v1) + ", popped " + int32ToString(v2) + " and then pushed into the stack their multiplication (" + int32ToString(mul) + ")"
+			return "Popped " + v1.String() + ", popped " + v2.String() + " and then pushed into the stack their multiplication (" + mul.String() + ")", nil
+		}
+	case OpMod: //Pops two numbers, and pushes the result of the modulus in the stack
+		v1, v2, err := popTwoOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		mod, err := i.arithmetic().rem(v2, v1)
+		if err != nil {
+			return "", err
+		}
+		if err := pushOrErr(i, mod); err != nil {
+			return "", err
 		}
-	case OPERATIONS["MOD"].String(): //Pops two numbers, and pushes the result of the modulus in the stack
-		v1 := popOrErr(i)
-		v2 := popOrErr(i)
-		mod := v2 % v1
-		pushOrErr(i, mod)
 		if i.isDebug {
-			return "Popped " + int32ToString(v1) + ", popped " + int32ToString(v2) + " and then pushed into the stack the result of their modulus (" + int32ToString(mod) + ")"
+			return "Popped " + v1.String() + ", popped " + v2.String() + " and then pushed into the stack the result of their modulus (" + mod.String() + ")", nil
+		}
+	case OpRnd: //Pops one number, and pushes in the stack a random number between [0, n[ where n is the number popped
+		n, err := popOrErr(i)
+		if err != nil {
+			return "", err
 		}
-	case OPERATIONS["RND"].String(): //Pops one number, and pushes in the stack a random number between [0, n[ where n is the number popped
-		n := popOrErr(i)
-		if n <= 0 {
-			logError(ErrorRandomGenerator)
+		if n.Type() != ValueInt {
+			return "", ErrorValueType
+		}
+		if n.Cmp(IntValue(0)) <= 0 {
+			return "", ErrorRandomGenerator
+		}
+		random := randomBelow(n)
+		if err := pushOrErr(i, random); err != nil {
+			return "", err
 		}
-		random := rand.Int31n(n)
-		pushOrErr(i, random)
 		if i.isDebug {
-			return "Random generated " + int32ToString(random) + " [range 0 to " + int32ToString(n-1) + "] and then pushed it into the stack"
+			last, _ := i.arithmetic().sub(n, IntValue(1))
+			return "Random generated " + random.String() + " [range 0 to " + last.String() + "] and then pushed it into the stack", nil
+		}
+	case OpAnd: //Pops two numbers, and pushes the result of AND [0 is false, anything else is true] [pushes 1 if true or 0 is false]
+		v1, v2, err := popTwoOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		result := v1.Bool() && v2.Bool()
+		if err := pushOrErr(i, IntValue(int64(Btoi(result)))); err != nil {
+			return "", err
 		}
-	case OPERATIONS["AND"].String(): //Pops two numbers, and pushes the result of AND [0 is false, anything else is true] [pushes 1 if true or 0 is false]
-		v1 := popOrErr(i)
-		v2 := popOrErr(i)
-		result := Itob(v1) && Itob(v2)
-		pushOrErr(i, int32(Btoi(result)))
 		if i.isDebug {
-			return "Popped " + int32ToString(v1) + ", popped " + int32ToString(v2) + " and then pushed into the stack the result of their logical AND (" + intToString(Btoi(result)) + ")"
+			return "Popped " + v1.String() + ", popped " + v2.String() + " and then pushed into the stack the result of their logical AND (" + intToString(Btoi(result)) + ")", nil
+		}
+	case OpOr: //Pops two numbers, and pushes the result of OR [0 is false, anything else is true] [pushes 1 if true or 0 is false]
+		v1, v2, err := popTwoOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		result := v1.Bool() || v2.Bool()
+		if err := pushOrErr(i, IntValue(int64(Btoi(result)))); err != nil {
+			return "", err
 		}
-	case OPERATIONS["OR"].String(): //Pops two numbers, and pushes the result of OR [0 is false, anything else is true] [pushes 1 if true or 0 is false]
-		v1 := popOrErr(i)
-		v2 := popOrErr(i)
-		result := Itob(v1) || Itob(v2)
-		pushOrErr(i, int32(Btoi(result)))
 		if i.isDebug {
-			return "Popped " + int32ToString(v1) + ", popped " + int32ToString(v2) + " and then pushed into the stack the result of their logical OR (" + intToString(Btoi(result)) + ")"
+			return "Popped " + v1.String() + ", popped " + v2.String() + " and then pushed into the stack the result of their logical OR (" + intToString(Btoi(result)) + ")", nil
+		}
+	case OpXor: //Pops two numbers, and pushes the result of XOR [0 is false, anything else is true] [pushes 1 if true or 0 is false]
+		v1, v2, err := popTwoOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		result := v1.Bool() != v2.Bool()
+		if err := pushOrErr(i, IntValue(int64(Btoi(result)))); err != nil {
+			return "", err
 		}
-	case OPERATIONS["XOR"].String(): //Pops two numbers, and pushes the result of XOR [0 is false, anything else is true] [pushes 1 if true or 0 is false]
-		v1 := popOrErr(i)
-		v2 := popOrErr(i)
-		result := Itob(v1) != Itob(v2)
-		pushOrErr(i, int32(Btoi(result)))
 		if i.isDebug {
-			return "Popped " + int32ToString(v1) + ", popped " + int32ToString(v2) + " and then pushed into the stack the result of their logical XOR (" + intToString(Btoi(result)) + ")"
+			return "Popped " + v1.String() + ", popped " + v2.String() + " and then pushed into the stack the result of their logical XOR (" + intToString(Btoi(result)) + ")", nil
+		}
+	case OpNand: //Pops two numbers, and pushes the result of NAND [0 is false, anything else is true] [pushes 1 if true or 0 is false]
+		v1, v2, err := popTwoOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		result := nand(v1.Bool(), v2.Bool())
+		if err := pushOrErr(i, IntValue(int64(Btoi(result)))); err != nil {
+			return "", err
 		}
-	case OPERATIONS["NAND"].String(): //Pops two numbers, and pushes the result of NAND [0 is false, anything else is true] [pushes 1 if true or 0 is false]
-		v1 := popOrErr(i)
-		v2 := popOrErr(i)
-		result := nand(Itob(v1), Itob(v2))
-		pushOrErr(i, int32(Btoi(result)))
 		if i.isDebug {
-			return "Popped " + int32ToString(v1) + ", popped " + int32ToString(v2) + " and then pushed into the stack the result of their logical NAND (" + intToString(Btoi(result)) + ")"
+			return "Popped " + v1.String() + ", popped " + v2.String() + " and then pushed into the stack the result of their logical NAND (" + intToString(Btoi(result)) + ")", nil
+		}
+	case OpNot: //Pops one number, and pushes the result of NOT [0 is false, anything else is true] [pushes 1 if true or 0 is false]
+		v1, err := popOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		result := Btoi(!v1.Bool())
+		if err := pushOrErr(i, IntValue(int64(result))); err != nil {
+			return "", err
 		}
-	case OPERATIONS["NOT"].String(): //Pops one number, and pushes the result of NOT [0 is false, anything else is true] [pushes 1 if true or 0 is false]
-		v1 := popOrErr(i)
-		result := Btoi(!Itob(v1))
-		pushOrErr(i, int32(result))
 		if i.isDebug {
-			return "Popped " + int32ToString(v1) + " from the stack and then pushed into the stack its logical NOT (" + intToString(result) + ")"
+			return "Popped " + v1.String() + " from the stack and then pushed into the stack its logical NOT (" + intToString(result) + ")", nil
+		}
+	case OpBand:
+		v1, v2, err := popTwoOrErr(i)
+		if err != nil {
+			return "", err
 		}
-	case OPERATIONS["BAND"].String():
-		v1 := popOrErr(i)
-		v2 := popOrErr(i)
 
-		result := v1 & v2
-		pushOrErr(i, result)
+		result, err := i.arithmetic().and(v1, v2)
+		if err != nil {
+			return "", err
+		}
+		if err := pushOrErr(i, result); err != nil {
+			return "", err
+		}
 
 		if i.isDebug {
-			return "Popped " + int32ToString(v1) + ", popped " + int32ToString(v2) + " and pushed into the stack the result of their bitwise AND (" + int32ToString(result) + ")"
+			return "Popped " + v1.String() + ", popped " + v2.String() + " and pushed into the stack the result of their bitwise AND (" + result.String() + ")", nil
+		}
+	case OpBor:
+		v1, v2, err := popTwoOrErr(i)
+		if err != nil {
+			return "", err
 		}
-	case OPERATIONS["BOR"].String():
-		v1 := popOrErr(i)
-		v2 := popOrErr(i)
 
-		result := v1 | v2
-		pushOrErr(i, result)
+		result, err := i.arithmetic().or(v1, v2)
+		if err != nil {
+			return "", err
+		}
+		if err := pushOrErr(i, result); err != nil {
+			return "", err
+		}
 
 		if i.isDebug {
-			return "Popped " + int32ToString(v1) + ", popped " + int32ToString(v2) + " and pushed into the stack the result of their bitwise OR (" + int32ToString(result) + ")"
+			return "Popped " + v1.String() + ", popped " + v2.String() + " and pushed into the stack the result of their bitwise OR (" + result.String() + ")", nil
+		}
+	case OpBxor:
+		v1, v2, err := popTwoOrErr(i)
+		if err != nil {
+			return "", err
 		}
-	case OPERATIONS["BXOR"].String():
-		v1 := popOrErr(i)
-		v2 := popOrErr(i)
 
-		result := v1 ^ v2
-		pushOrErr(i, result)
+		result, err := i.arithmetic().xor(v1, v2)
+		if err != nil {
+			return "", err
+		}
+		if err := pushOrErr(i, result); err != nil {
+			return "", err
+		}
 
 		if i.isDebug {
-			return "Popped " + int32ToString(v1) + ", popped " + int32ToString(v2) + " and pushed into the stack the result of their bitwise XOR (" + int32ToString(result) + ")"
+			return "Popped " + v1.String() + ", popped " + v2.String() + " and pushed into the stack the result of their bitwise XOR (" + result.String() + ")", nil
+		}
+	case OpBnot:
+		v1, err := popOrErr(i)
+		if err != nil {
+			return "", err
 		}
-	case OPERATIONS["BNOT"].String():
-		v1 := popOrErr(i)
 
-		result := ^v1
-		pushOrErr(i, result)
+		result, err := i.arithmetic().not(v1)
+		if err != nil {
+			return "", err
+		}
+		if err := pushOrErr(i, result); err != nil {
+			return "", err
+		}
 
 		if i.isDebug {
-			return "Popped " + int32ToString(v1) + " from the stack and then pushed into the stack its bitwise NOT (" + int32ToString(result) + ")"
+			return "Popped " + v1.String() + " from the stack and then pushed into the stack its bitwise NOT (" + result.String() + ")", nil
+		}
+	case OpLshift:
+		v1, v2, err := popTwoOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		result, err := i.arithmetic().shift(v2, v1, true)
+		if err != nil {
+			return "", err
+		}
+		if err := pushOrErr(i, result); err != nil {
+			return "", err
 		}
-	case OPERATIONS["LSHIFT"].String():
-		v1 := popOrErr(i)
-		v2 := popOrErr(i)
-		result := v2 << v1
-		pushOrErr(i, result)
 		if i.isDebug {
-			return "Popped " + int32ToString(v1) + ", popped " + int32ToString(v2) + " and then pushed into the stack the result of their left bit shifting (" + int32ToString(result) + ")"
+			return "Popped " + v1.String() + ", popped " + v2.String() + " and then pushed into the stack the result of their left bit shifting (" + result.String() + ")", nil
+		}
+	case OpRshift:
+		v1, v2, err := popTwoOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		result, err := i.arithmetic().shift(v2, v1, false)
+		if err != nil {
+			return "", err
+		}
+		if err := pushOrErr(i, result); err != nil {
+			return "", err
 		}
-	case OPERATIONS["RSHIFT"].String():
-		v1 := popOrErr(i)
-		v2 := popOrErr(i)
-		result := v2 >> v1
-		pushOrErr(i, result)
 		if i.isDebug {
-			return "Popped " + int32ToString(v1) + ", popped " + int32ToString(v2) + " and then pushed into the stack the result of their right bit shifting (" + int32ToString(result) + ")"
+			return "Popped " + v1.String() + ", popped " + v2.String() + " and then pushed into the stack the result of their right bit shifting (" + result.String() + ")", nil
+		}
+	case OpPop: //Pops one number, and discardes it
+		v, err := popOrErr(i)
+		if err != nil {
+			return "", err
 		}
-	case OPERATIONS["POP"].String(): //Pops one number, and discardes it
-		v := popOrErr(i)
 		if i.isDebug {
-			return "Popped " + int32ToString(v) + " from the stack"
+			return "Popped " + v.String() + " from the stack", nil
+		}
+	case OpSwap: //Swaps the top two items in the stack
+		v1, v2, err := popTwoOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		if err := pushOrErr(i, v1); err != nil {
+			return "", err
+		}
+		if err := pushOrErr(i, v2); err != nil {
+			return "", err
 		}
-	case OPERATIONS["SWAP"].String(): //Swaps the top two items in the stack
-		v1 := popOrErr(i)
-		v2 := popOrErr(i)
-		pushOrErr(i, v1)
-		pushOrErr(i, v2)
 		if i.isDebug {
-			return "Popped " + int32ToString(v1) + ", popped " + int32ToString(v2) + " and pushed in reverse order to swap them"
+			return "Popped " + v1.String() + ", popped " + v2.String() + " and pushed in reverse order to swap them", nil
 		}
-	case OPERATIONS["CYCLE"].String(): //Cycles clockwise the stack
+	case OpCycle: //Cycles clockwise the stack
 		i.stack.Cycle()
 		if i.isDebug {
-			return "Cycled clockwise by one step the stack"
+			return "Cycled clockwise by one step the stack", nil
 		}
-	case OPERATIONS["RCYCLE"].String(): //Cycles anti-clockwise the stack
+	case OpRcycle: //Cycles anti-clockwise the stack
 		i.stack.RCycle()
 		if i.isDebug {
-			return "Cycled counter-clockwise by one step the stack"
+			return "Cycled counter-clockwise by one step the stack", nil
+		}
+	case OpDup: //Duplicates the top of the stack
+		val, err := popOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		if err := pushOrErr(i, val); err != nil {
+			return "", err
+		}
+		if err := pushOrErr(i, val); err != nil {
+			return "", err
 		}
-	case OPERATIONS["DUP"].String(): //Duplicates the top of the stack
-		val := popOrErr(i)
-		pushOrErr(i, val)
-		pushOrErr(i, val)
 		if i.isDebug {
-			return "Popped " + int32ToString(val) + " and then pushed it twice to duplicate it"
+			return "Popped " + val.String() + " and then pushed it twice to duplicate it", nil
 		}
-	case OPERATIONS["REVERSE"].String(): //Reverses the content of the stack
+	case OpReverse: //Reverses the content of the stack
 		i.stack.Reverse()
 		if i.isDebug {
-			return "Reversed stack content"
+			return "Reversed stack content", nil
+		}
+	case OpOver: //Pushes a copy of the second item of the stack
+		val, err := i.stack.Pick(1)
+		if err != nil {
+			return "", ErrorPop
+		}
+		if err := pushOrErr(i, val); err != nil {
+			return "", err
 		}
-	case OPERATIONS["QUIT"].String(): //Exits the program
-		fmt.Printf("\n")
-		os.Exit(0)
-	case OPERATIONS["OUTPUT"].String(): //Outputs all the content of the stack without popping it
-		i.stack.Output()
 		if i.isDebug {
-			return "Outputted all the stack content"
+			return "Pushed a copy of the second item of the stack (" + val.String() + ")", nil
+		}
+	case OpRot: //Moves the third item of the stack to the top
+		if i.stack.Roll(2) != nil {
+			return "", ErrorPop
 		}
-	case OPERATIONS["WHILE"].String():
-		if i.stack.Peek() == 0 { //exits the loop if top is false
-			jumpForward(i)
+		if i.isDebug {
+			return "Moved the third item of the stack (" + i.stack.Peek().String() + ") to the top", nil
+		}
+	case OpPick: //Pops n and pushes a copy of the n-th item of the stack
+		n, err := popOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		depth, ok := n.Int64()
+		if !ok {
+			return "", ErrorInvalidStackIndex
+		}
+		val, err := i.stack.Pick(int(depth))
+		if err != nil {
+			return "", err
+		}
+		if err := pushOrErr(i, val); err != nil {
+			return "", err
+		}
+		if i.isDebug {
+			return "Popped " + n.String() + " and then pushed a copy of the item at that depth (" + val.String() + ")", nil
+		}
+	case OpRoll: //Pops n and moves the n-th item of the stack to the top
+		n, err := popOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		depth, ok := n.Int64()
+		if !ok {
+			return "", ErrorInvalidStackIndex
+		}
+		if err := i.stack.Roll(int(depth)); err != nil {
+			return "", err
+		}
+		if i.isDebug {
+			return "Popped " + n.String() + " and then moved the item at that depth (" + i.stack.Peek().String() + ") to the top", nil
+		}
+	case OpDepth: //Pushes the number of items in the stack
+		size := IntValue(int64(i.stack.Size()))
+		if err := pushOrErr(i, size); err != nil {
+			return "", err
+		}
+		if i.isDebug {
+			return "Pushed the number of items in the stack (" + size.String() + ")", nil
+		}
+	case OpClear: //Removes all the items of the stack
+		i.stack.Clear()
+		if i.isDebug {
+			return "Cleared the stack", nil
+		}
+	case OpQuit: //Stops the program as a normal termination
+		if _, err := fmt.Fprintf(i.output, "\n"); err != nil {
+			return "", ErrorWriteOutput
+		}
+		i.halted = true
+		i.exitCode = 0
+		return "Quit the program", nil
+	case OpOutput: //Outputs all the content of the stack without popping it
+		if !i.stack.Output(i.output) {
+			return "", ErrorWriteOutput
+		}
+		if i.isDebug {
+			return "Outputted all the stack content", nil
+		}
+	case OpWhile:
+		if !i.stack.Peek().Bool() { //exits the loop if top is false
+			i.pc = i.program.jumps[i.pc] // the program continues after the matching WHILE_END
 			if i.isDebug {
-				return "Jumped forward for while loop"
+				return "Jumped forward for while loop", nil
 			}
 		}
 		if i.isDebug {
-			return "Entered in while loop"
+			return "Entered in while loop", nil
 		}
-	case OPERATIONS["WHILE_END"].String():
-		jumpBack(i)
+	case OpWhileEnd:
+		jump(i, i.program.jumps[i.pc]) // the matching WHILE checks again the top of the stack
 		if i.isDebug {
-			return "Jumped back for while loop"
+			return "Jumped back for while loop", nil
 		}
-	case OPERATIONS["FILE_OPEN"].String():
+	case OpIf: //Pops one number: if it is false skips to the matching ELSE or END_IF
+		val, err := popOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		if !val.Bool() {
+			i.pc = i.program.jumps[i.pc] // the program continues after the matching ELSE or END_IF
+			if i.isDebug {
+				return "Popped " + val.String() + " and jumped forward to the else block", nil
+			}
+		}
+		if i.isDebug {
+			return "Popped " + val.String() + " and entered in the if block", nil
+		}
+	case OpElse: //Reached at the end of the if block, skips the else block
+		i.pc = i.program.jumps[i.pc] // the program continues after the matching END_IF
+		if i.isDebug {
+			return "Jumped forward to the end of the if", nil
+		}
+	case OpEndIf:
+		if i.isDebug {
+			return "Exited the if", nil
+		}
+	case OpEq: //Pops two numbers, and pushes 1 if they are equal or 0 if they are not
+		return compare(i, "==", func(c int) bool { return c == 0 })
+	case OpNeq: //Pops two numbers, and pushes 1 if they are not equal or 0 if they are
+		return compare(i, "!=", func(c int) bool { return c != 0 })
+	case OpGt: //Pops b and a, and pushes 1 if a > b or 0 otherwise
+		return compare(i, ">", func(c int) bool { return c > 0 })
+	case OpLt: //Pops b and a, and pushes 1 if a < b or 0 otherwise
+		return compare(i, "<", func(c int) bool { return c < 0 })
+	case OpGte: //Pops b and a, and pushes 1 if a >= b or 0 otherwise
+		return compare(i, ">=", func(c int) bool { return c >= 0 })
+	case OpLte: //Pops b and a, and pushes 1 if a <= b or 0 otherwise
+		return compare(i, "<=", func(c int) bool { return c <= 0 })
+	case OpLabel: //Skips its name: the routine is executed only when called
+		name := i.program.code[i.pc+1].Value
+		i.pc = skipName(i, i.pc)
+		if i.isDebug {
+			return "Skipped label " + int32ToString(name), nil
+		}
+	case OpCall: //Executes the routine of the LABEL with the same name, then continues after the name of the CALL
+		if err := call(i); err != nil {
+			return "", err
+		}
+		if i.isDebug {
+			return "Called routine " + int32ToString(i.program.code[i.pc].Value), nil
+		}
+	case OpReturn: //Ends the routine and continues after the last CALL, or ends the import of the last module
+		frame, err := ret(i)
+		if err != nil {
+			return "", err
+		}
+		if frame.module != "" {
+			return "Imported module " + frame.module, nil
+		}
+		if i.isDebug {
+			return "Returned from routine " + int32ToString(frame.program.code[frame.pc+1].Value), nil
+		}
+	case OpImport: //Pops a string: imports the routines of the image with that path, executing its instructions
+		name, err := buildStringFromStack(i)
+		if err != nil {
+			return "", err
+		}
+		return importModule(i, name)
+	case OpFileOpen:
 		if hasOpenedFile(i) {
-			logError(ErrorFileAlreadyOpen)
+			return "", ErrorFileAlreadyOpen
 		}
-		var err error = nil
 		fileName, err := buildStringFromStack(i)
-		checkError(err, err)
-		i.openedFile, err = openFile(fileName)
-		checkError(err, err)
-		return "Opened file " + i.openedFile.Name()
-	case OPERATIONS["FILE_CLOSE"].String():
+		if err != nil {
+			return "", err
+		}
+		i.openedFile, err = openFile(i, fileName)
+		if err != nil {
+			return "", err
+		}
+		return "Opened file " + i.openedFile.Name(), nil
+	case OpFileClose:
+		if !hasOpenedFile(i) {
+			return "", ErrorNoOpenedFile
+		}
 		fileName := i.openedFile.Name()
 		err := i.openedFile.Close()
-		checkError(err, ErrorCloseFile)
+		if err != nil {
+			return "", ErrorCloseFile
+		}
 		i.openedFile = nil
-		return "Closed file " + fileName
-	default: //every color not in the list above pushes into the stack the sum of red, green and blue values of the pixel
-		sum := int32(pixel.R) + int32(pixel.G) + int32(pixel.B)
-		pushOrErr(i, sum)
-		return "Pushed " + intToString(int(sum)) + " into the stack"
-	}
-	return ""
-}
-
-// Checks if error is not nil. If is not nil, throws the second param error.
-func checkError(e error, errorToLaunch error) {
-	if e != nil {
-		logError(errorToLaunch)
+		return "Closed file " + fileName, nil
+	case OpNop: //Does nothing
+		return "Skipped a no-operation instruction", nil
+	case OpTurnRight: //Turns the direction pointer by 90 degrees clockwise
+		if i.mode != ModeDirectional {
+			return "", ErrorDirectionalOnly
+		}
+		i.direction = i.direction.Clockwise()
+		return "Turned " + i.direction.String(), nil
+	case OpTurnLeft: //Turns the direction pointer by 90 degrees counterclockwise
+		if i.mode != ModeDirectional {
+			return "", ErrorDirectionalOnly
+		}
+		i.direction = i.direction.Counterclockwise()
+		return "Turned " + i.direction.String(), nil
+	case OpReflect: //Reverses the direction pointer
+		if i.mode != ModeDirectional {
+			return "", ErrorDirectionalOnly
+		}
+		i.direction = i.direction.Reverse()
+		return "Turned " + i.direction.String(), nil
+	case OpTurnIf: //Pops one number and turns the direction pointer clockwise if it is true, otherwise keeps the direction
+		if i.mode != ModeDirectional {
+			return "", ErrorDirectionalOnly
+		}
+		val, err := popOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		if val.Bool() {
+			i.direction = i.direction.Clockwise()
+			return "Turned " + i.direction.String(), nil
+		}
+		return "Kept direction " + i.direction.String(), nil
+	case OpLiteral: //Pushes the value of the following pixel and skips it
+		if err := skipLiteralValues(i, 1); err != nil {
+			return "", err
+		}
+		if err := pushOrErr(i, IntValue(int64(ins.Value))); err != nil {
+			return "", err
+		}
+		if i.isDebug {
+			return "Pushed literal " + int32ToString(ins.Value) + " into the stack", nil
+		}
+	case OpFloatLiteral: //Pushes the float of the following two pixels and skips them
+		if err := skipLiteralValues(i, 2); err != nil {
+			return "", err
+		}
+		val := FloatValue(ins.Float)
+		if err := pushOrErr(i, val); err != nil {
+			return "", err
+		}
+		if i.isDebug {
+			return "Pushed float literal " + val.String() + " into the stack", nil
+		}
+	case OpIntToFloat: //Pops an integer and pushes it as a float
+		v, err := popOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		result, err := i.arithmetic().toFloat(v)
+		if err != nil {
+			return "", err
+		}
+		if err := pushOrErr(i, result); err != nil {
+			return "", err
+		}
+		if i.isDebug {
+			return "Popped " + v.String() + " and then pushed it into the stack as a float (" + result.String() + ")", nil
+		}
+	case OpFloatToInt: //Pops a float and pushes it as an integer, truncated toward zero
+		v, err := popOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		result, err := i.arithmetic().toInt(v)
+		if err != nil {
+			return "", err
+		}
+		if err := pushOrErr(i, result); err != nil {
+			return "", err
+		}
+		if i.isDebug {
+			return "Popped " + v.String() + " and then pushed it into the stack as an integer (" + result.String() + ")", nil
+		}
+	case OpFloatSum: //Pops two floats, adds them and pushes the result in the stack
+		return floatOperation(i, "+", func(a float64, b float64) float64 { return a + b })
+	case OpFloatSub: //Pops b and a, and pushes a - b
+		return floatOperation(i, "-", func(a float64, b float64) float64 { return a - b })
+	case OpFloatMul: //Pops two floats, multiplies them and pushes the result in the stack
+		return floatOperation(i, "*", func(a float64, b float64) float64 { return a * b })
+	case OpFloatDiv: //Pops b and a, and pushes a / b
+		return floatOperation(i, "/", func(a float64, b float64) float64 { return a / b })
+	case OpInputFloat: //Gets value from input as float and pushes it to the stack
+		if hasOpenedFile(i) { // as INPUT_INT, pushes the content of the opened file as a string
+			content, err := readFromFile(i)
+			if err != nil {
+				return "", err
+			}
+			return "Pushed " + truncateString(content, 50) + " into the stack", nil
+		}
+		var f float64
+		if err := scanfOrErr(i, "%g\n", &f); err != nil {
+			return "", err
+		}
+		val := FloatValue(f)
+		if err := pushOrErr(i, val); err != nil {
+			return "", err
+		}
+		if i.isDebug {
+			return "Pushed " + val.String() + " into the stack", nil
+		}
+	case OpOutputFloat: //Pops the top of the stack and outputs it as float
+		return outputNumber(i, ValueFloat)
+	case OpStore: //Pops an address and a value, and writes the value in the heap at that address
+		return store(i)
+	case OpLoad: //Pops an address and pushes the value of the heap at that address
+		return load(i)
+	case OpPush: //every color not in the list above pushes into the stack the sum of red, green and blue values of the pixel
+		if err := pushOrErr(i, IntValue(int64(ins.Value))); err != nil {
+			return "", err
+		}
+		return "Pushed " + int32ToString(ins.Value) + " into the stack", nil
 	}
-}
-
-// Logs an error to stdout and stops the interpreter
-func logError(e error) {
-	fmt.Printf("\n")
-	log.Println("\033[31m" + e.Error() + "\033[0m")
-	os.Exit(2)
+	return "", nil
 }
 
 // Converts an integer to bool
@@ -519,128 +1173,128 @@ func Btoi(b bool) int {
 	return 0
 }
 
-// Returns the result of a NAND b
-func nand(a bool, b bool) bool {
-	return !(a && b)
+/*
+ * Pops b and a, the second number from the top, and pushes 1 if the relation between a and b holds or 0 if it doesn't.
+ * Operands follow the order of SUB, so pushing a and then b compares a with b.
+ */
+func compare(i *Interpreter, relation string, holds func(c int) bool) (string, error) {
+	b, a, err := popTwoOrErr(i)
+	if err != nil {
+		return "", err
+	}
+	result := IntValue(int64(Btoi(holds(a.Cmp(b)))))
+	if a.isNaN() || b.isNaN() {
+		// NaN is not ordered: only != holds
+		result = IntValue(int64(Btoi(relation == "!=")))
+	}
+	if err := pushOrErr(i, result); err != nil {
+		return "", err
+	}
+	if i.isDebug {
+		return "Popped " + b.String() + ", popped " + a.String() + " and then pushed into the stack the result of " +
+			a.String() + " " + relation + " " + b.String() + " (" + result.String() + ")", nil
+	}
+	return "", nil
 }
 
-// Jumps forward to the corresponding end while operation
-func jumpForward(i *Interpreter) {
-	open := 0
-	for {
-		p := i.readPixel()
-		err := i.increasePC()
-		switch p.String() {
-		case OPERATIONS["WHILE"].String():
-			open++
-		case OPERATIONS["WHILE_END"].String():
-			open--
-			if open == 0 {
-				return
-			}
-		}
-		checkError(err, ErrorMissingEndLoop)
+// Pops b and a, the second float from the top, and pushes the result of the operation a op b
+func floatOperation(i *Interpreter, operator string, op func(a float64, b float64) float64) (string, error) {
+	b, a, err := popTwoOrErr(i)
+	if err != nil {
+		return "", err
+	}
+	result, err := applyFloat(a, b, op)
+	if err != nil {
+		return "", err
+	}
+	if err := pushOrErr(i, result); err != nil {
+		return "", err
+	}
+	if i.isDebug {
+		return "Popped " + b.String() + ", popped " + a.String() + " and then pushed into the stack the result of " +
+			a.String() + " " + operator + " " + b.String() + " (" + result.String() + ")", nil
 	}
+	return "", nil
 }
 
-// Jumps back to the corresponding open while operation
-func jumpBack(i *Interpreter) {
-	closed := 0
-	for {
-		p := i.readPixel()
-		err := i.decreasePC()
-		switch p.String() {
-		case OPERATIONS["WHILE"].String():
-			closed--
-			if closed == 0 {
-				return
-			}
-		case OPERATIONS["WHILE_END"].String():
-			closed++
+// Pops the top of the stack, which must be of the given type, and writes it to the opened file or to the output
+func outputNumber(i *Interpreter, typ ValueType) (string, error) {
+	if hasOpenedFile(i) && i.fileAccess == FileAccessReadOnly {
+		return "", ErrorFileReadOnly
+	}
+	val, err := popOrErr(i)
+	if err != nil {
+		return "", err
+	}
+	if val.Type() != typ {
+		return "", ErrorValueType
+	}
+	if hasOpenedFile(i) {
+		_, err := i.openedFile.WriteString(val.String())
+		if err != nil {
+			return "", ErrorWriteFile
+		}
+		return "Wrote " + val.String() + " to the opened file (" + i.openedFile.Name() + ")", nil
+	} else {
+		if _, err := fmt.Fprintf(i.output, "%s", val); err != nil {
+			return "", ErrorWriteOutput
 		}
-		checkError(err, ErrorMissingStartLoop)
 	}
+	if i.isDebug {
+		return "Popped " + val.String() + " from the stack and printed it in the console", nil
+	}
+	return "", nil
 }
 
-// Increases program counter
-func (i *Interpreter) increasePC() error {
-	if i.pc.X+i.instructionSize < i.width {
-		i.pc.X = i.pc.X + i.instructionSize
-		return nil
-	}
-	if i.pc.Y+i.instructionSize < i.height {
-		i.pc.Y = i.pc.Y + i.instructionSize
-		i.pc.X = 0
-		return nil
-	}
-	return ErrorOutOfBounds
+// Returns the result of a NAND b
+func nand(a bool, b bool) bool {
+	return !(a && b)
+}
+
+// Moves the program counter to the given instruction, which will be the next one executed
+func jump(i *Interpreter, target int) {
+	i.pc = target
+	i.jumped = true
 }
 
-// Decreases program counter
-func (i *Interpreter) decreasePC() error {
-	if i.pc.X-i.instructionSize >= 0 {
-		i.pc.X = i.pc.X - i.instructionSize
+// Moves the program counter to the next instruction: the following one in linear mode, the one pointed by the direction in directional mode
+func (i *Interpreter) increasePC() error {
+	if i.mode == ModeDirectional {
+		next, ok := i.program.Neighbor(i.pc, i.direction)
+		if !ok {
+			return ErrorOutOfBounds
+		}
+		i.pc = next
 		return nil
 	}
-	if i.pc.Y-i.instructionSize >= 0 {
-		i.pc.Y = i.pc.Y - i.instructionSize
-		i.pc.X = i.width - i.instructionSize
+	if i.pc+1 < i.program.Len() {
+		i.pc++
 		return nil
 	}
 	return ErrorOutOfBounds
 }
 
-// Converts a string representing an hex value to a Pixel structure. An error will be throwed if the format is wrong.
-func hexToPixel(s string) (p *Pixel, err error) {
-	var r, g, b int32
-	switch len(s) {
-	case 6:
-		_, err = fmt.Sscanf(s, "%2x%2x%2x", &r, &g, &b)
-		checkError(err, ErrorInvalidHex)
-		return &Pixel{R: uint8(r), G: uint8(g), B: uint8(b)}, nil
-	case 3:
-		_, err = fmt.Sscanf(s, "%1x%1x%1x", &r, &g, &b)
-		checkError(err, ErrorInvalidHex)
-		// Double the hex digits:
-		r *= 17
-		g *= 17
-		b *= 17
-		return &Pixel{R: uint8(r), G: uint8(g), B: uint8(b)}, nil
-	default:
-		err = ErrorInvalidHex
-		return nil, err
+// Prints the message of the last executed step, the stack and the next instruction to execute
+func debug(i *Interpreter, step int, message string) {
+	fmt.Fprintf(i.debugOutput, "\n############ Step %d ############\n", step)
+	fmt.Fprintf(i.debugOutput, "Message: \033[33m%s\033[0m", message)
+	printStack(i)
+	pos := i.program.Position(i.pc)
+	fmt.Fprintf(i.debugOutput, "\nNext: (%d, %d) -> %s", pos.X, pos.Y, i.program.code[i.pc])
+	if i.mode == ModeDirectional {
+		fmt.Fprintf(i.debugOutput, ", direction: %s", i.direction)
 	}
-}
-
-// Loads configs from the given config file and overrides standard operations color codes with the custom ones
-func LoadConfigs(path string) error {
-	cfg, err := ini.Load(path)
-	if err != nil {
-		return ErrorLoadConfig
+	if inModule(i) {
+		fmt.Fprintf(i.debugOutput, ", module: %s", i.program.path)
 	}
-	for op := range OPERATIONS {
-		value := cfg.Section("Colors").Key(op).String()
-		if len(value) != 0 {
-			newPx, err := hexToPixel(value)
-			if err != nil {
-				return ErrorInvalidHex
-			}
-			OPERATIONS[op] = newPx
-		}
-	}
-	return err
 }
 
-// Displays a debug message and the stack content in the specified step
-func debug(i *Interpreter, step int, message string) {
-	fmt.Printf("\n############ Step %d ############\n", step)
-	fmt.Printf("Message: \033[33m%s\033[0m", message)
+// Prints the content of the stack, from the top to the bottom, with the type of each value
+func printStack(i *Interpreter) {
 	for index := i.stack.Size() - 1; index >= 0; index-- {
-		val, err := i.stack.GetItemAt(index)
-		checkError(err, ErrorInvalidStackIndex)
-		fmt.Printf("\n|%8d|", val)
+		val, _ := i.stack.GetItemAt(index)
+		fmt.Fprintf(i.debugOutput, "\n|%8s| %s", val, val.Type())
 	}
-	fmt.Print("\nPress ENTER to step over:")
 }
 
 func buildStringFromStack(i *Interpreter) (string, error) {
@@ -649,7 +1303,15 @@ func buildStringFromStack(i *Interpreter) (string, error) {
 		ch     rune   = ' '
 	)
 	for index := i.stack.Size() - 1; index >= 0; index-- {
-		ch = rune(popOrErr(i))
+		val, err := popOrErr(i)
+		if err != nil {
+			return "", err
+		}
+		code, ok := val.Int64()
+		if !ok {
+			return "", ErrorInvalidString
+		}
+		ch = rune(code)
 		if ch == '\000' {
 			return result, nil
 		}
@@ -658,19 +1320,6 @@ func buildStringFromStack(i *Interpreter) (string, error) {
 	return "", ErrorInvalidString
 }
 
-func openFile(path string) (*os.File, error) {
-	var (
-		err  error
-		file *os.File
-	)
-	x := os.O_CREATE | os.O_APPEND | os.O_RDWR
-	file, err = os.OpenFile(path, x, 0644)
-	if err != nil {
-		return nil, ErrorOpenFile
-	}
-	return file, nil
-}
-
 func hasOpenedFile(i *Interpreter) bool {
 	return i.openedFile != nil
 }
@@ -692,7 +1341,9 @@ func readFromFile(i *Interpreter) (string, error) {
 
 	var content string
 
-	pushOrErr(i, int32('\000'))
+	if err := pushOrErr(i, IntValue('\000')); err != nil {
+		return "", err
+	}
 	for ch != '\000' {
 		ch, _, err := reader.ReadRune()
 
@@ -706,7 +1357,9 @@ func readFromFile(i *Interpreter) (string, error) {
 			break
 		}
 		content += string(ch)
-		pushOrErr(i, int32(ch))
+		if err := pushOrErr(i, IntValue(int64(ch))); err != nil {
+			return "", err
+		}
 	}
 	return content, nil
 }
@@ -720,5 +1373,8 @@ func intToString(n int) string {
 }
 
 func isEnoughSpaceForString(i *Interpreter, s string) bool {
-	return (len(s) + 1) < (i.stack.maxSize - i.stack.Size())
+	if i.stack.maxSize == -1 {
+		return true
+	}
+	return (len(s) + 1) <= (i.stack.maxSize - i.stack.Size())
 }
@@ -1,10 +1,19 @@
 package interpreter
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"image"
+	"image/png"
+	"io"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 const (
@@ -14,13 +23,14 @@ const (
 
 var (
 	filledStack = &Stack{
-		items:   []int32{10, 20, 30, 40, 50},
+		items:   intValues(10, 20, 30, 40, 50),
 		maxSize: 20,
 	}
 	stack, _   = NewStack(-1)
-	imgFile, _ = os.Open("..\\examples\\tests\\load_image.png")
+	imgFile, _ = os.Open(filepath.Join("..", "examples", "tests", "load_image.png"))
 	img, _, _  = image.Decode(imgFile)
-	r, g, b, _ = img.At(0, 0).RGBA()
+
+	testProgram, _ = Compile(img, NewInstructionSet(), 200)
 )
 
 func TestNewInterpreter(t *testing.T) {
@@ -30,9 +40,10 @@ func TestNewInterpreter(t *testing.T) {
 		instructionSize int
 	}
 	tests := []struct {
-		name string
-		args args
-		want *Interpreter
+		name    string
+		args    args
+		want    *Interpreter
+		wantErr bool
 	}{
 		{
 			name: "New Interpreter test 1",
@@ -42,17 +53,20 @@ func TestNewInterpreter(t *testing.T) {
 				instructionSize: 0,
 			},
 			want: &Interpreter{
-				image: nil,
-				stack: stack,
-				pc: image.Point{
-					X: 0,
-					Y: 0,
-				},
+				image:           nil,
+				stack:           stack,
+				pc:              0,
 				width:           0,
 				height:          0,
 				isDebug:         false,
 				instructionSize: 0,
 				openedFile:      nil,
+				instructions:    NewInstructionSet(),
+				input:           bufio.NewReader(os.Stdin),
+				output:          os.Stdout,
+				debugOutput:     os.Stdout,
+				maxCallDepth:    DefaultMaxCallDepth,
+				heap:            make([]Value, DefaultHeapSize),
 			},
 		},
 		{
@@ -63,23 +77,41 @@ func TestNewInterpreter(t *testing.T) {
 				instructionSize: 0,
 			},
 			want: &Interpreter{
-				image: nil,
-				stack: stack,
-				pc: image.Point{
-					X: 0,
-					Y: 0,
-				},
+				image:           nil,
+				stack:           stack,
+				pc:              0,
 				width:           0,
 				height:          0,
 				isDebug:         false,
 				instructionSize: 0,
 				openedFile:      nil,
+				instructions:    NewInstructionSet(),
+				input:           bufio.NewReader(os.Stdin),
+				output:          os.Stdout,
+				debugOutput:     os.Stdout,
+				maxCallDepth:    DefaultMaxCallDepth,
+				heap:            make([]Value, DefaultHeapSize),
 			},
 		},
+		{
+			name: "New Interpreter with invalid max size",
+			args: args{
+				debug:           false,
+				maxSize:         -2,
+				instructionSize: 0,
+			},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewInterpreter(tt.args.debug, tt.args.maxSize, tt.args.instructionSize); !reflect.DeepEqual(got, tt.want) {
+			got, err := NewInterpreter(tt.args.debug, tt.args.maxSize, tt.args.instructionSize)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewInterpreter() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewInterpreter() = %v, want %v", got, tt.want)
 			}
 		})
@@ -99,80 +131,84 @@ func TestInterpreter_LoadImage(t *testing.T) {
 		{
 			name: "Load without errors",
 			i: &Interpreter{
-				image: nil,
-				stack: stack,
-				pc: image.Point{
-					X: 0,
-					Y: 0,
-				},
+				image:           nil,
+				stack:           stack,
+				pc:              0,
 				width:           0,
 				height:          0,
 				isDebug:         false,
-				instructionSize: 0,
+				instructionSize: 200,
 				openedFile:      nil,
+				instructions:    NewInstructionSet(),
 			},
 			args: args{
-				path: "..\\examples\\tests\\load_image.png",
+				path: filepath.Join("..", "examples", "tests", "load_image.png"),
 			},
 			wantErr: false,
 		},
 		{
 			name: "Load with open error",
 			i: &Interpreter{
-				image: nil,
-				stack: stack,
-				pc: image.Point{
-					X: 0,
-					Y: 0,
-				},
+				image:           nil,
+				stack:           stack,
+				pc:              0,
 				width:           0,
 				height:          0,
 				isDebug:         false,
 				instructionSize: 0,
 				openedFile:      nil,
+				instructions:    NewInstructionSet(),
 			},
 			args: args{
-				path: "..\\examples\\test\\load_image.png",
+				path: filepath.Join("..", "examples", "test", "load_image.png"),
 			},
 			wantErr: true,
 		},
 		{
 			name: "Load with extension error",
 			i: &Interpreter{
-				image: nil,
-				stack: stack,
-				pc: image.Point{
-					X: 0,
-					Y: 0,
-				},
+				image:           nil,
+				stack:           stack,
+				pc:              0,
 				width:           0,
 				height:          0,
 				isDebug:         false,
 				instructionSize: 0,
 				openedFile:      nil,
+				instructions:    NewInstructionSet(),
 			},
 			args: args{
-				path: "..\\examples\\tests\\load_image.txt",
+				path: filepath.Join("..", "examples", "tests", "load_image.txt"),
 			},
 			wantErr: true,
 		},
 		{
 			name: "Load with decode error",
 			i: &Interpreter{
-				image: nil,
-				stack: stack,
-				pc: image.Point{
-					X: 0,
-					Y: 0,
-				},
+				image:           nil,
+				stack:           stack,
+				pc:              0,
 				width:           0,
 				height:          0,
 				isDebug:         false,
 				instructionSize: 0,
 				openedFile:      nil,
+				instructions:    NewInstructionSet(),
 			},
 			args: args{
-				path: "..\\examples\\tests\\load_image_invalid.png",
+				path: filepath.Join("..", "examples", "tests", "load_image_invalid.png"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Load with invalid instruction size",
+			i: &Interpreter{
+				stack:           stack,
+				instructionSize: 0,
+				instructions:    NewInstructionSet(),
+			},
+			args: args{
+				path: filepath.Join("..", "examples", "tests", "load_image.png"),
 			},
 			wantErr: true,
 		},
@@ -186,6 +222,35 @@ func TestInterpreter_LoadImage(t *testing.T) {
 	}
 }
 
+func TestInterpreter_LoadImageFromReaderReuse(t *testing.T) {
+	var out bytes.Buffer
+	i, err := NewInterpreterWithOptions(WithOutput(&out))
+	if err != nil {
+		t.Fatalf("NewInterpreterWithOptions() error = %v", err)
+	}
+	// The first program stops with QUIT, the second one must run from its first instruction to its end
+	for _, source := range []string{"push 1 output_int quit push 9 output_int", "push 2 output_int push 3 output_int"} {
+		img, err := Assemble(strings.NewReader(source), i.instructions, 1)
+		if err != nil {
+			t.Fatalf("Assemble() error = %v", err)
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatalf("png.Encode() error = %v", err)
+		}
+		if err := i.LoadImageFromReader(&buf); err != nil {
+			t.Fatalf("Interpreter.LoadImageFromReader() error = %v", err)
+		}
+		out.Reset()
+		if _, err := i.Run(); err != nil {
+			t.Fatalf("Interpreter.Run() error = %v", err)
+		}
+	}
+	if got := out.String(); got != "23" {
+		t.Errorf("Interpreter.Run() output of the second program = %q, want %q", got, "23")
+	}
+}
+
 func TestInterpreter_Run(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -195,41 +260,45 @@ func TestInterpreter_Run(t *testing.T) {
 		{
 			name: "Running without debugging",
 			i: &Interpreter{
-				image: img,
-				stack: stack,
-				pc: image.Point{
-					X: 0,
-					Y: 0,
-				},
+				image:           img,
+				program:         testProgram,
+				stack:           stack,
+				pc:              0,
 				width:           IMG_WIDTH,
 				height:          IMG_HEIGHT,
 				isDebug:         false,
 				instructionSize: 200,
 				openedFile:      nil,
+				instructions:    NewInstructionSet(),
+				input:           bufio.NewReader(strings.NewReader("")),
+				output:          io.Discard,
+				debugOutput:     io.Discard,
 			},
-			wantErr: true,
+			wantErr: false,
 		},
 		{
 			name: "Running with debugger",
 			i: &Interpreter{
-				image: img,
-				stack: stack,
-				pc: image.Point{
-					X: 0,
-					Y: 0,
-				},
+				image:           img,
+				program:         testProgram,
+				stack:           stack,
+				pc:              0,
 				width:           IMG_WIDTH,
 				height:          IMG_HEIGHT,
 				isDebug:         true,
 				instructionSize: 200,
 				openedFile:      nil,
+				instructions:    NewInstructionSet(),
+				input:           bufio.NewReader(strings.NewReader("")),
+				output:          io.Discard,
+				debugOutput:     io.Discard,
 			},
-			wantErr: true,
+			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := tt.i.Run(); (err != nil) != tt.wantErr {
+			if _, err := tt.i.Run(); (err != nil) != tt.wantErr {
 				t.Errorf("Interpreter.Run() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -238,25 +307,25 @@ func TestInterpreter_Run(t *testing.T) {
 
 func TestInterpreter_Step(t *testing.T) {
 	tests := []struct {
-		name  string
-		i     *Interpreter
-		want  bool
-		want1 string
+		name    string
+		i       *Interpreter
+		want    bool
+		want1   string
+		wantErr bool
 	}{
 		{
 			name: "Single step test 1",
 			i: &Interpreter{
-				image: img,
-				stack: stack,
-				pc: image.Point{
-					X: 0,
-					Y: 0,
-				},
+				image:           img,
+				program:         testProgram,
+				stack:           stack,
+				pc:              0,
 				width:           IMG_WIDTH,
 				height:          IMG_HEIGHT,
 				isDebug:         false,
 				instructionSize: 200,
 				openedFile:      &os.File{},
+				instructions:    NewInstructionSet(),
 			},
 			want:  true,
 			want1: "Pushed 0 into the stack",
@@ -264,17 +333,16 @@ func TestInterpreter_Step(t *testing.T) {
 		{
 			name: "Single step test 2",
 			i: &Interpreter{
-				image: img,
-				stack: stack,
-				pc: image.Point{
-					X: 200,
-					Y: 0,
-				},
+				image:           img,
+				program:         testProgram,
+				stack:           stack,
+				pc:              1,
 				width:           IMG_WIDTH - 1,
 				height:          IMG_HEIGHT - 1,
 				isDebug:         true,
 				instructionSize: 200,
 				openedFile:      &os.File{},
+				instructions:    NewInstructionSet(),
 			},
 			want:  true,
 			want1: "Pushed 108 into the stack",
@@ -282,7 +350,11 @@ func TestInterpreter_Step(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, got1 := tt.i.Step()
+			got, got1, err := tt.i.Step()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Interpreter.Step() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
 			if got != tt.want {
 				t.Errorf("Interpreter.Step() got = %v, want %v", got, tt.want)
 			}
@@ -293,74 +365,38 @@ func TestInterpreter_Step(t *testing.T) {
 	}
 }
 
-func TestInterpreter_readPixel(t *testing.T) {
+func Test_readPixel(t *testing.T) {
 	tests := []struct {
 		name string
-		i    *Interpreter
+		x    int
+		y    int
 		want *Pixel
 	}{
 		{
 			name: "Read pixel test",
-			i: &Interpreter{
-				image: img,
-				stack: stack,
-				pc: image.Point{
-					X: 0,
-					Y: 0,
-				},
-				width:           IMG_WIDTH,
-				height:          IMG_HEIGHT,
-				isDebug:         false,
-				instructionSize: 0,
-				openedFile:      &os.File{},
-			},
+			x:    0,
+			y:    0,
 			want: &Pixel{
 				R: 0,
 				G: 0,
 				B: 0,
 			},
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.i.readPixel(); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("Interpreter.readPixel() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
-func Test_rgbaToPixel(t *testing.T) {
-	type args struct {
-		r   uint32
-		g   uint32
-		b   uint32
-		in3 uint32
-	}
-	tests := []struct {
-		name string
-		args args
-		want *Pixel
-	}{
 		{
-			name: "rgb to Pixel structure test",
-			args: args{
-				r:   r,
-				g:   g,
-				b:   b,
-				in3: 0,
-			},
+			name: "Read pixel test 2",
+			x:    200,
+			y:    0,
 			want: &Pixel{
-				R: 0,
-				G: 0,
-				B: 0,
+				R: 34,
+				G: 49,
+				B: 25,
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := rgbaToPixel(tt.args.r, tt.args.g, tt.args.b, tt.args.in3); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("rgbaToPixel() = %v, want %v", got, tt.want)
+			if got := readPixel(img, tt.x, tt.y); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readPixel() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -373,7 +409,7 @@ func Test_popOrErr(t *testing.T) {
 	tests := []struct {
 		name string
 		args args
-		want int32
+		want Value
 	}{
 		{
 			name: "Stack pop test",
@@ -381,20 +417,26 @@ func Test_popOrErr(t *testing.T) {
 				i: &Interpreter{
 					image:           nil,
 					stack:           filledStack,
-					pc:              image.Point{},
+					pc:              0,
 					width:           IMG_WIDTH,
 					height:          IMG_HEIGHT,
 					isDebug:         false,
 					instructionSize: 0,
 					openedFile:      &os.File{},
+					instructions:    NewInstructionSet(),
 				},
 			},
-			want: 50,
+			want: IntValue(50),
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := popOrErr(tt.args.i); got != tt.want {
+			got, err := popOrErr(tt.args.i)
+			if err != nil {
+				t.Errorf("popOrErr() error = %v", err)
+				return
+			}
+			if got != tt.want {
 				t.Errorf("popOrErr() = %v, want %v", got, tt.want)
 			}
 		})
@@ -404,7 +446,7 @@ func Test_popOrErr(t *testing.T) {
 func Test_pushOrErr(t *testing.T) {
 	type args struct {
 		i   *Interpreter
-		val int32
+		val Value
 	}
 	tests := []struct {
 		name string
@@ -414,55 +456,57 @@ func Test_pushOrErr(t *testing.T) {
 			name: "Push stack test",
 			args: args{
 				i: &Interpreter{
-					image: img,
-					stack: filledStack,
-					pc: image.Point{
-						X: 0,
-						Y: 0,
-					},
+					image:           img,
+					program:         testProgram,
+					stack:           filledStack,
+					pc:              0,
 					width:           IMG_WIDTH,
 					height:          IMG_HEIGHT,
 					isDebug:         false,
 					instructionSize: 0,
 					openedFile:      &os.File{},
+					instructions:    NewInstructionSet(),
 				},
-				val: 20,
+				val: IntValue(20),
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			pushOrErr(tt.args.i, tt.args.val)
+			if err := pushOrErr(tt.args.i, tt.args.val); err != nil {
+				t.Errorf("pushOrErr() error = %v", err)
+			}
 		})
 	}
 }
 
-func Test_processPixel(t *testing.T) {
+func Test_processInstruction(t *testing.T) {
 	type args struct {
-		pixel *Pixel
-		i     *Interpreter
+		ins Instruction
+		i   *Interpreter
 	}
 	tests := []struct {
-		name string
-		args args
-		want string
+		name    string
+		args    args
+		want    string
+		wantErr error
 	}{
 		{
 			name: "OUTPUT_INT",
 			args: args{
-				pixel: &Pixel{R: 0, G: 0, B: 1},
+				ins: Instruction{Op: OpOutputInt},
 				i: &Interpreter{
-					image: img,
-					stack: filledStack,
-					pc: image.Point{
-						X: 0,
-						Y: 0,
-					},
+					image:           img,
+					program:         testProgram,
+					stack:           filledStack,
+					pc:              0,
 					width:           IMG_WIDTH,
 					height:          IMG_HEIGHT,
 					isDebug:         true,
 					instructionSize: 200,
 					openedFile:      nil,
+					instructions:    NewInstructionSet(),
+					output:          io.Discard,
 				},
 			},
 			want: "Popped 20 from the stack and printed it in the console",
@@ -470,29 +514,350 @@ func Test_processPixel(t *testing.T) {
 		{
 			name: "SUM",
 			args: args{
-				pixel: &Pixel{R: 0, G: 206, B: 209},
+				ins: Instruction{Op: OpSum},
 				i: &Interpreter{
-					image: img,
-					stack: filledStack,
-					pc: image.Point{
-						X: 0,
-						Y: 0,
-					},
+					image:           img,
+					program:         testProgram,
+					stack:           filledStack,
+					pc:              0,
 					width:           IMG_WIDTH,
 					height:          IMG_HEIGHT,
 					isDebug:         true,
 					instructionSize: 200,
 					openedFile:      nil,
+					instructions:    NewInstructionSet(),
 				},
 			},
 			want: "Popped 40, popped 30 and then pushed into the stack their sum (70)",
 		},
+		{
+			name: "SUM with empty stack",
+			args: args{
+				ins: Instruction{Op: OpSum},
+				i: &Interpreter{
+					stack:           &Stack{maxSize: -1},
+					instructions:    NewInstructionSet(),
+					isDebug:         true,
+					instructionSize: 1,
+				},
+			},
+			want:    "",
+			wantErr: ErrorPop,
+		},
+		{
+			name: "RND with invalid range",
+			args: args{
+				ins: Instruction{Op: OpRnd},
+				i: &Interpreter{
+					stack:           &Stack{items: intValues(0), maxSize: -1},
+					instructions:    NewInstructionSet(),
+					isDebug:         true,
+					instructionSize: 1,
+				},
+			},
+			want:    "",
+			wantErr: ErrorRandomGenerator,
+		},
+		{
+			name: "FILE_CLOSE without opened file",
+			args: args{
+				ins: Instruction{Op: OpFileClose},
+				i: &Interpreter{
+					stack:           &Stack{maxSize: -1},
+					instructions:    NewInstructionSet(),
+					isDebug:         true,
+					instructionSize: 1,
+				},
+			},
+			want:    "",
+			wantErr: ErrorNoOpenedFile,
+		},
+		{
+			name: "Push in full stack",
+			args: args{
+				ins: Instruction{Op: OpPush, Value: 6},
+				i: &Interpreter{
+					stack:           &Stack{items: intValues(10), maxSize: 1},
+					instructions:    NewInstructionSet(),
+					isDebug:         true,
+					instructionSize: 1,
+				},
+			},
+			want:    "",
+			wantErr: ErrorFullStack,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := processPixel(tt.args.pixel, tt.args.i); got != tt.want {
-				t.Errorf("processPixel() = %v, want %v", got, tt.want)
+			got, err := processInstruction(tt.args.ins, tt.args.i)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("processInstruction() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("processInstruction() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestInterpreter_StepErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		i       *Interpreter
+		program []*Pixel
+		want    *RuntimeError
+		running bool
+	}{
+		{
+			name:    "Stack underflow",
+			i:       &Interpreter{stack: &Stack{items: intValues(4), maxSize: -1}, instructions: NewInstructionSet(), pc: 2},
+			program: []*Pixel{{R: 1}, {R: 3}, OPERATIONS["SUM"]},
+			want: &RuntimeError{
+				Op:        "SUM",
+				PC:        image.Point{X: 2, Y: 0},
+				StackSize: 1,
+				Err:       ErrorPop,
+			},
+			running: false,
+		},
+		{
+			name:    "Quit is a normal termination",
+			i:       &Interpreter{stack: &Stack{maxSize: -1}, instructions: NewInstructionSet(), output: io.Discard},
+			program: []*Pixel{OPERATIONS["QUIT"]},
+			want:    nil,
+			running: false,
+		},
+		{
+			name:    "Push keeps running",
+			i:       &Interpreter{stack: &Stack{maxSize: -1}, instructions: NewInstructionSet()},
+			program: []*Pixel{{R: 1, G: 1, B: 1}},
+			want:    nil,
+			running: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pc := tt.i.pc
+			loadTestProgram(tt.i, tt.program...)
+			tt.i.pc = pc
+
+			running, _, err := tt.i.Step()
+			if running != tt.running {
+				t.Errorf("Interpreter.Step() running = %v, want %v", running, tt.running)
+			}
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("Interpreter.Step() error = %v, want nil", err)
+				}
+				return
+			}
+			var got *RuntimeError
+			if !errors.As(err, &got) {
+				t.Fatalf("Interpreter.Step() error = %v, want *RuntimeError", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Interpreter.Step() error = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpreter_RunLoops(t *testing.T) {
+	while, end, sub, out := OPERATIONS["WHILE"], OPERATIONS["WHILE_END"], OPERATIONS["SUB"], OPERATIONS["OUTPUT_INT"]
+	tests := []struct {
+		name    string
+		program []*Pixel
+		want    string
+	}{
+		{
+			name:    "Count down",
+			program: []*Pixel{{R: 3}, while, OPERATIONS["DUP"], out, {R: 1}, sub, end, out},
+			want:    "3210",
+		},
+		{
+			name:    "Skipped loop",
+			program: []*Pixel{{R: 0}, while, {R: 9}, out, end, {R: 5}, out},
+			want:    "5",
+		},
+		{
+			name:    "Nested loops",
+			program: []*Pixel{{R: 2}, while, {R: 2}, while, OPERATIONS["DUP"], out, {R: 1}, sub, end, OPERATIONS["POP"], {R: 1}, sub, end},
+			want:    "2121",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			i, _ := NewInterpreterWithOptions(WithOutput(output))
+			loadTestProgram(i, tt.program...)
+			if _, err := i.Run(); err != nil {
+				t.Fatalf("Interpreter.Run() error = %v", err)
+			}
+			if got := output.String(); got != tt.want {
+				t.Errorf("Interpreter.Run() output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpreter_RunConditionals(t *testing.T) {
+	ifOp, elseOp, endIf, out := OPERATIONS["IF"], OPERATIONS["ELSE"], OPERATIONS["END_IF"], OPERATIONS["OUTPUT_INT"]
+	tests := []struct {
+		name    string
+		program []*Pixel
+		want    string
+	}{
+		{name: "Equal", program: []*Pixel{{R: 4}, {R: 4}, OPERATIONS["EQ"], out}, want: "1"},
+		{name: "Not equal", program: []*Pixel{{R: 4}, {R: 4}, OPERATIONS["NEQ"], out}, want: "0"},
+		{name: "Greater than", program: []*Pixel{{R: 5}, {R: 3}, OPERATIONS["GT"], out}, want: "1"},
+		{name: "Less than", program: []*Pixel{{R: 5}, {R: 3}, OPERATIONS["LT"], out}, want: "0"},
+		{name: "Greater than or equal", program: []*Pixel{{R: 3}, {R: 3}, OPERATIONS["GTE"], out}, want: "1"},
+		{name: "Less than or equal", program: []*Pixel{{R: 5}, {R: 3}, OPERATIONS["LTE"], out}, want: "0"},
+		{
+			name:    "If true",
+			program: []*Pixel{{R: 1}, ifOp, {R: 7}, out, elseOp, {R: 8}, out, endIf, {R: 9}, out},
+			want:    "79",
+		},
+		{
+			name:    "If false",
+			program: []*Pixel{{R: 0}, ifOp, {R: 7}, out, elseOp, {R: 8}, out, endIf, {R: 9}, out},
+			want:    "89",
+		},
+		{
+			name:    "If false without else",
+			program: []*Pixel{{R: 0}, ifOp, {R: 7}, out, endIf, {R: 9}, out},
+			want:    "9",
+		},
+		{
+			name:    "Nested ifs",
+			program: []*Pixel{{R: 1}, {R: 0}, ifOp, {R: 7}, out, elseOp, ifOp, {R: 8}, out, endIf, endIf},
+			want:    "8",
+		},
+		{
+			name:    "Comparison as condition",
+			program: []*Pixel{{R: 3}, {R: 6}, OPERATIONS["GT"], ifOp, {R: 3}, out, elseOp, {R: 6}, out, endIf},
+			want:    "6",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			i, _ := NewInterpreterWithOptions(WithOutput(output))
+			loadTestProgram(i, tt.program...)
+			if _, err := i.Run(); err != nil {
+				t.Fatalf("Interpreter.Run() error = %v", err)
+			}
+			if got := output.String(); got != tt.want {
+				t.Errorf("Interpreter.Run() output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpreter_RunContext(t *testing.T) {
+	infinite := []*Pixel{{R: 1}, OPERATIONS["WHILE"], OPERATIONS["WHILE_END"]}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithTimeout(context.Background(), -time.Second)
+	defer cancelExpired()
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		opts      []Option
+		program   []*Pixel
+		wantErr   error
+		wantSteps int
+	}{
+		{
+			name:      "Step limit",
+			ctx:       context.Background(),
+			opts:      []Option{WithMaxSteps(10)},
+			program:   infinite,
+			wantErr:   ErrorStepLimit,
+			wantSteps: 10,
+		},
+		{
+			name:    "Program ending before the step limit",
+			ctx:     context.Background(),
+			opts:    []Option{WithMaxSteps(3)},
+			program: []*Pixel{{R: 1}, {R: 2}, OPERATIONS["SUM"]},
+		},
+		{
+			name:    "Timeout",
+			ctx:     context.Background(),
+			opts:    []Option{WithTimeout(10 * time.Millisecond)},
+			program: infinite,
+			wantErr: ErrorTimeout,
+		},
+		{
+			name:    "Context deadline",
+			ctx:     expired,
+			program: infinite,
+			wantErr: ErrorTimeout,
+		},
+		{
+			name:    "Context canceled",
+			ctx:     canceled,
+			program: infinite,
+			wantErr: ErrorCanceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, err := NewInterpreterWithOptions(append(tt.opts, WithOutput(io.Discard))...)
+			if err != nil {
+				t.Fatalf("NewInterpreterWithOptions() error = %v", err)
+			}
+			loadTestProgram(i, tt.program...)
+			_, err = i.RunContext(tt.ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Interpreter.RunContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("Interpreter.RunContext() error = %T, want *LimitError", err)
+			}
+			if tt.wantSteps > 0 && limitErr.Steps != tt.wantSteps {
+				t.Errorf("LimitError.Steps = %d, want %d", limitErr.Steps, tt.wantSteps)
+			}
+			if want := i.program.Position(i.pc); limitErr.PC != want {
+				t.Errorf("LimitError.PC = %v, want %v", limitErr.PC, want)
+			}
+		})
+	}
+}
+
+// Loads into the interpreter a single row program made of the given pixels
+func loadTestProgram(i *Interpreter, pixels ...*Pixel) {
+	img := newTestImage(len(pixels), 1, pixels...)
+	i.image = img
+	i.program, _ = Compile(img, i.instructions, 1)
+	i.pc = 0
+	i.width, i.height = img.Bounds().Max.X, img.Bounds().Max.Y
+}
+
+/*
+ * Assembles the source with 1px instructions and runs it in a new interpreter created with the given options.
+ * Returns the interpreter, what the program wrote to its output and the error returned by Run.
+ */
+func runTestSource(t *testing.T, source string, opts ...Option) (*Interpreter, string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	i, err := NewInterpreterWithOptions(append(append([]Option{}, opts...), WithOutput(&out))...)
+	if err != nil {
+		t.Fatalf("NewInterpreterWithOptions() error = %v", err)
+	}
+	img, err := Assemble(strings.NewReader(source), i.instructions, 1)
+	if err != nil {
+		t.Fatalf("Assemble() error = %v", err)
+	}
+	if i.program, err = Compile(img, i.instructions, 1); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	_, err = i.Run()
+	return i, out.String(), err
+}
@@ -0,0 +1,95 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Range of the values written in the pixels following a LITERAL or a FLOAT_LITERAL, signed 24 bits integers
+const (
+	MinLiteral = -1 << 23
+	MaxLiteral = 1<<23 - 1
+)
+
+var (
+	ErrorMissingLiteralValue = errors.New("error: missing value pixel after literal")
+	ErrorLiteralRange        = errors.New("error: literal value must be an integer between -8388608 and 8388607")
+	ErrorFloatLiteralRange   = errors.New("error: float literal must be a finite number whose significant digits are an integer between -8388608 and 8388607")
+	ErrorLiteralDirection    = errors.New("error: in directional mode literals must be reached moving right, with their values in the same row")
+)
+
+// Returns the number of value pixels following the instruction: 1 for LITERAL, 2 for FLOAT_LITERAL and 0 for the others
+func literalValues(op Opcode) int {
+	switch op {
+	case OpLiteral:
+		return 1
+	case OpFloatLiteral:
+		return 2
+	}
+	return 0
+}
+
+/*
+ * Moves the program counter to the last value pixel of the literal it points to, so that the execution continues after it.
+ * The value pixels follow the literal in reading order, so in directional mode they are skipped only if the direction
+ * pointer moves right and they are in the same row of the literal, otherwise ErrorLiteralDirection is returned.
+ */
+func skipLiteralValues(i *Interpreter, values int) error {
+	if i.mode == ModeDirectional && (i.direction != DirectionRight || i.pc%i.program.columns+values >= i.program.columns) {
+		return ErrorLiteralDirection
+	}
+	i.pc += values
+	return nil
+}
+
+// Returns the value written in the red, green and blue bits of the pixel, as a two's complement 24 bits integer
+func literalValue(p *Pixel) int32 {
+	v := int32(p.R)<<16 | int32(p.G)<<8 | int32(p.B)
+	if v > MaxLiteral {
+		v -= 1 << 24
+	}
+	return v
+}
+
+// Returns the pixel holding the given value in its red, green and blue bits. The second value is false if n is out of range.
+func literalValuePixel(n int) (Pixel, bool) {
+	if n < MinLiteral || n > MaxLiteral {
+		return Pixel{}, false
+	}
+	v := uint32(n) & 0xffffff
+	return Pixel{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v)}, true
+}
+
+// Returns the float mantissa * 10^exponent, written in the two pixels following a FLOAT_LITERAL, rounded to the nearest float
+func floatLiteral(mantissa int32, exponent int32) float64 {
+	// Exponents out of the range of the floats give an infinity or zero
+	f, _ := strconv.ParseFloat(fmt.Sprintf("%de%d", mantissa, exponent), 64)
+	return f
+}
+
+/*
+ * Returns the mantissa and the exponent pixels of a FLOAT_LITERAL of the given float, with the shortest mantissa that reads back the same float.
+ * The last value is false if f is not finite or if the mantissa is out of the range of the literals.
+ */
+func floatLiteralPixels(f float64) (Pixel, Pixel, bool) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Pixel{}, Pixel{}, false
+	}
+	// e.g. -1.25e+03 is mantissa -125 and exponent 1
+	digits, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	exponent, _ := strconv.Atoi(exp)
+	if whole, fraction, ok := strings.Cut(digits, "."); ok {
+		digits = whole + fraction
+		exponent -= len(fraction)
+	}
+	mantissa, _ := strconv.Atoi(digits)
+	m, ok := literalValuePixel(mantissa)
+	if !ok {
+		return Pixel{}, Pixel{}, false
+	}
+	e, _ := literalValuePixel(exponent)
+	return m, e, true
+}
@@ -0,0 +1,171 @@
+package interpreter
+
+import (
+	"errors"
+	"image"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func Test_literalValuePixel(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		want Pixel
+		ok   bool
+	}{
+		{name: "Zero", n: 0, want: Pixel{}, ok: true},
+		{name: "Positive", n: 100000, want: Pixel{R: 0x01, G: 0x86, B: 0xa0}, ok: true},
+		{name: "Minus one", n: -1, want: Pixel{R: 0xff, G: 0xff, B: 0xff}, ok: true},
+		{name: "Max", n: MaxLiteral, want: Pixel{R: 0x7f, G: 0xff, B: 0xff}, ok: true},
+		{name: "Min", n: MinLiteral, want: Pixel{R: 0x80}, ok: true},
+		{name: "Above max", n: MaxLiteral + 1},
+		{name: "Below min", n: MinLiteral - 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := literalValuePixel(tt.n)
+			if ok != tt.ok || got != tt.want {
+				t.Fatalf("literalValuePixel() = %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+			if ok && literalValue(&got) != int32(tt.n) {
+				t.Errorf("literalValue() = %d, want %d", literalValue(&got), tt.n)
+			}
+		})
+	}
+}
+
+func Test_floatLiteralPixels(t *testing.T) {
+	tests := []struct {
+		name     string
+		f        float64
+		mantissa int32
+		exponent int32
+		ok       bool
+	}{
+		{name: "Zero", f: 0, ok: true},
+		{name: "Integer", f: 1200, mantissa: 12, exponent: 2, ok: true},
+		{name: "Fraction", f: -3.14, mantissa: -314, exponent: -2, ok: true},
+		{name: "Big exponent", f: 6.02214e23, mantissa: 602214, exponent: 18, ok: true},
+		{name: "Smallest float", f: 5e-324, mantissa: 5, exponent: -324, ok: true},
+		{name: "Max mantissa", f: 8.388607, mantissa: MaxLiteral, exponent: -6, ok: true},
+		{name: "Too many digits", f: 8.388608},
+		{name: "Not a number", f: math.NaN()},
+		{name: "Infinity", f: math.Inf(-1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mantissa, exponent, ok := floatLiteralPixels(tt.f)
+			if ok != tt.ok {
+				t.Fatalf("floatLiteralPixels() ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if m, e := literalValue(&mantissa), literalValue(&exponent); m != tt.mantissa || e != tt.exponent {
+				t.Fatalf("floatLiteralPixels() = %de%d, want %de%d", m, e, tt.mantissa, tt.exponent)
+			}
+			if got := floatLiteral(tt.mantissa, tt.exponent); got != tt.f {
+				t.Errorf("floatLiteral() = %v, want %v", got, tt.f)
+			}
+		})
+	}
+	if got := floatLiteral(1, MaxLiteral); !math.IsInf(got, 1) {
+		t.Errorf("floatLiteral() = %v, want +Inf", got)
+	}
+}
+
+func TestCompile_Literal(t *testing.T) {
+	set := NewInstructionSet()
+	literal, _ := set.Color("LITERAL")
+	floatLiteral, _ := set.Color("FLOAT_LITERAL")
+	outputInt, _ := set.Color("OUTPUT_INT")
+	tests := []struct {
+		name    string
+		img     image.Image
+		want    []Instruction
+		wantErr error
+	}{
+		{
+			name: "Value matching an operation color",
+			img:  newTestImage(3, 1, &literal, &outputInt, &outputInt),
+			want: []Instruction{{Op: OpLiteral, Value: 1}, {Op: OpPush, Value: 1}, {Op: OpOutputInt}},
+		},
+		{
+			name: "Value on the next row",
+			img:  newTestImage(2, 2, &Pixel{R: 1}, &literal, &Pixel{R: 0xff, G: 0xff, B: 0xfe}, &outputInt),
+			want: []Instruction{{Op: OpPush, Value: 1}, {Op: OpLiteral, Value: -2}, {Op: OpPush, Value: -2}, {Op: OpOutputInt}},
+		},
+		{
+			name:    "Missing value",
+			img:     newTestImage(2, 1, &outputInt, &literal),
+			wantErr: &CompileError{Op: "LITERAL", Pos: image.Point{X: 1, Y: 0}, Err: ErrorMissingLiteralValue},
+		},
+		{
+			name: "Float mantissa and exponent",
+			img:  newTestImage(4, 1, &floatLiteral, &Pixel{R: 0xff, G: 0xff, B: 0xfb}, &Pixel{R: 0xff, G: 0xff, B: 0xff}, &outputInt),
+			want: []Instruction{{Op: OpFloatLiteral, Value: -5, Float: -0.5}, {Op: OpPush, Value: -5}, {Op: OpPush, Value: -1}, {Op: OpOutputInt}},
+		},
+		{
+			name:    "Missing float exponent",
+			img:     newTestImage(3, 1, &outputInt, &floatLiteral, &outputInt),
+			wantErr: &CompileError{Op: "FLOAT_LITERAL", Pos: image.Point{X: 1, Y: 0}, Err: ErrorMissingLiteralValue},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, err := Compile(tt.img, set, 1)
+			if tt.wantErr != nil {
+				if !reflect.DeepEqual(err, tt.wantErr) {
+					t.Errorf("Compile() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			if !reflect.DeepEqual(program.code, tt.want) {
+				t.Errorf("Compile() = %v, want %v", program.code, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpreter_RunLiteral(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		source  string
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "Big and negative values",
+			source: "literal 8388607 output_int literal -8388608 output_int push 32 literal 1 sum output_int",
+			want:   "8388607-838860833",
+		},
+		{
+			name:   "Same value as a push",
+			source: "literal 42 push 42 eq output_int",
+			want:   "1",
+		},
+		{
+			name:    "Full stack",
+			opts:    []Option{WithMaxSize(1)},
+			source:  "push 1 literal 1000",
+			wantErr: ErrorFullStack,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := runTestSource(t, tt.source, tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Interpreter.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if out != tt.want {
+				t.Errorf("Interpreter.Run() output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
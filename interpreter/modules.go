@@ -0,0 +1,105 @@
+package interpreter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+var ErrorImportCycle = errors.New("error: trying to import a module that is importing this one")
+
+// Image imported by an IMPORT. imported is false while the instructions of the module are executed.
+type module struct {
+	program  *Program
+	imported bool
+}
+
+// Routine defined by a LABEL of the main program or of an imported module
+type routine struct {
+	program *Program
+	index   int
+}
+
+/*
+ * Imports the module at the given path, resolved from the directory of the image executing the IMPORT.
+ * The labels of the module become callable, then the instructions of the module are executed from the first one
+ * until a RETURN or the end of the module, so that a module can import other modules.
+ * A module is imported once: importing it again has no effect, while importing a module that is still
+ * executing its instructions, e.g. the main program or a module importing this one, returns ErrorImportCycle.
+ */
+func importModule(i *Interpreter, name string) (string, error) {
+	path, err := resolveModulePath(i, name)
+	if err != nil {
+		return "", err
+	}
+	if m, ok := i.modules[path]; ok {
+		if !m.imported {
+			return "", ErrorImportCycle
+		}
+		return "Module " + path + " already imported", nil
+	}
+	if len(i.callStack) >= i.maxCallDepth {
+		return "", ErrorCallDepth
+	}
+	program, err := LoadProgram(path, i.instructions, i.instructionSize)
+	if err != nil {
+		return "", err
+	}
+	program.path = path
+	if err := addRoutines(i, program); err != nil {
+		return "", err
+	}
+	i.modules[path] = &module{program: program}
+	i.callStack = append(i.callStack, callFrame{pc: i.pc, direction: i.direction, program: i.program, module: path})
+	i.program, i.pc, i.direction = program, 0, DirectionRight
+	i.jumped = true // the first instruction of the module is the next one executed
+	return "Importing module " + path, nil
+}
+
+/*
+ * Returns the absolute path of the module with the given name, relative to the directory of the running image
+ * or to the working directory if the path of the image is unknown. The module must follow the file policy of the interpreter.
+ */
+func resolveModulePath(i *Interpreter, name string) (string, error) {
+	if i.fileAccess == FileAccessDisabled {
+		return "", ErrorFileAccessDisabled
+	}
+	path := name
+	if !filepath.IsAbs(path) && i.program.path != "" {
+		path = filepath.Join(filepath.Dir(i.program.path), path)
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", ErrorOpenImage
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	} else if os.IsNotExist(err) {
+		return "", ErrorOpenImage
+	}
+	if i.fileRoot != "" && !isInsideDir(i.fileRoot, path) {
+		return "", ErrorFileOutsideRoot
+	}
+	return path, nil
+}
+
+// Makes the labels of the program callable by the other modules. Names must be unique among all the modules.
+func addRoutines(i *Interpreter, program *Program) error {
+	if i.routines == nil {
+		i.routines = make(map[int32]routine)
+	}
+	for name := range program.labels {
+		if _, ok := i.routines[name]; ok {
+			return ErrorDuplicateLabel
+		}
+	}
+	for name, index := range program.labels {
+		i.routines[name] = routine{program: program, index: index}
+	}
+	return nil
+}
+
+// Returns true if the interpreter is executing the instructions of an imported module
+func inModule(i *Interpreter) bool {
+	return i.main != nil && i.program != i.main
+}
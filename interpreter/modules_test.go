@@ -0,0 +1,189 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Returns the source pushing the given string with the \0 delimiter, as read by FILE_OPEN and IMPORT
+func pushString(str string) string {
+	tokens := []string{"push 0"}
+	for index := len(str) - 1; index >= 0; index-- {
+		tokens = append(tokens, "push "+strconv.Itoa(int(str[index])))
+	}
+	return strings.Join(tokens, " ")
+}
+
+// Assembles the source and writes the image at the given path, inside dir
+func writeTestModule(t *testing.T, dir string, path string, source string) string {
+	t.Helper()
+	img, err := Assemble(strings.NewReader(source), NewInstructionSet(), 1)
+	if err != nil {
+		t.Fatalf("Assemble() error = %v", err)
+	}
+	full := filepath.Join(dir, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		t.Fatalf("unable to create the module directory: %v", err)
+	}
+	f, err := os.Create(full)
+	if err != nil {
+		t.Fatalf("unable to create the module: %v", err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	return full
+}
+
+func TestInterpreter_RunImport(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		// When set, the file root is the directory of the main program instead of its parent
+		sandboxed bool
+		modules   map[string]string
+		want      string
+		wantErr   error
+	}{
+		{
+			name: "Call a routine of a module",
+			modules: map[string]string{
+				"main.png": pushString("lib.png") + " import call push 5",
+				"lib.png":  "return label push 5 push 42 output_int return",
+			},
+			want: "42",
+		},
+		{
+			name: "Module instructions executed once",
+			modules: map[string]string{
+				"main.png": pushString("lib.png") + " import " + pushString("lib.png") + " import push 1 output_int",
+				"lib.png":  "push 7 output_int",
+			},
+			want: "71",
+		},
+		{
+			name: "Modules relative to the importing image",
+			modules: map[string]string{
+				"main.png":  pushString("sub/a.png") + " import call push 9",
+				"sub/a.png": pushString("b.png") + " import",
+				"sub/b.png": "return label push 9 push 9 output_int return",
+				"b.png":     "return label push 9 push 1 output_int return",
+			},
+			want: "9",
+		},
+		{
+			name: "Routine not defined by any module",
+			modules: map[string]string{
+				"main.png": pushString("lib.png") + " import call push 3",
+				"lib.png":  "return label push 1 return",
+			},
+			wantErr: ErrorUndefinedLabel,
+		},
+		{
+			name: "Main program imported by a module",
+			modules: map[string]string{
+				"main.png": pushString("lib.png") + " import",
+				"lib.png":  pushString("main.png") + " import",
+			},
+			wantErr: ErrorImportCycle,
+		},
+		{
+			name: "Modules importing each other",
+			modules: map[string]string{
+				"main.png": pushString("a.png") + " import",
+				"a.png":    pushString("b.png") + " import",
+				"b.png":    pushString("a.png") + " import",
+			},
+			wantErr: ErrorImportCycle,
+		},
+		{
+			name: "Missing module",
+			modules: map[string]string{
+				"main.png": pushString("missing.png") + " import",
+			},
+			wantErr: ErrorOpenImage,
+		},
+		{
+			name: "Same label in two modules",
+			modules: map[string]string{
+				"main.png": pushString("lib.png") + " import quit label push 1 return",
+				"lib.png":  "return label push 1 return",
+			},
+			wantErr: ErrorDuplicateLabel,
+		},
+		{
+			name: "File operations disabled",
+			opts: []Option{WithFileAccess(FileAccessDisabled)},
+			modules: map[string]string{
+				"main.png": pushString("lib.png") + " import",
+				"lib.png":  "return",
+			},
+			wantErr: ErrorFileAccessDisabled,
+		},
+		{
+			name:      "Module outside the file root",
+			sandboxed: true,
+			modules: map[string]string{
+				"main.png": pushString("../lib.png") + " import",
+			},
+			wantErr: ErrorFileOutsideRoot,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "project")
+			for path, source := range tt.modules {
+				writeTestModule(t, dir, path, source)
+			}
+			writeTestModule(t, filepath.Dir(dir), "lib.png", "return")
+			root := filepath.Dir(dir)
+			if tt.sandboxed {
+				root = dir
+			}
+			var out bytes.Buffer
+			opts := append([]Option{WithOutput(&out), WithFileRoot(root)}, tt.opts...)
+			i, err := NewInterpreterWithOptions(opts...)
+			if err != nil {
+				t.Fatalf("NewInterpreterWithOptions() error = %v", err)
+			}
+			if err := i.LoadImage(filepath.Join(dir, "main.png")); err != nil {
+				t.Fatalf("Interpreter.LoadImage() error = %v", err)
+			}
+			_, err = i.Run()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Interpreter.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("Interpreter.Run() output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpreter_RunImportError(t *testing.T) {
+	dir := t.TempDir()
+	lib := writeTestModule(t, dir, "lib.png", "pop")
+	main := writeTestModule(t, dir, "main.png", pushString("lib.png")+" import")
+	i, err := NewInterpreterWithOptions(WithOutput(&bytes.Buffer{}))
+	if err != nil {
+		t.Fatalf("NewInterpreterWithOptions() error = %v", err)
+	}
+	if err := i.LoadImage(main); err != nil {
+		t.Fatalf("Interpreter.LoadImage() error = %v", err)
+	}
+	_, err = i.Run()
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) || !errors.Is(err, ErrorPop) {
+		t.Fatalf("Interpreter.Run() error = %v, want *RuntimeError", err)
+	}
+	if want, _ := filepath.EvalSymlinks(lib); runtimeErr.Module != want {
+		t.Errorf("RuntimeError.Module = %q, want %q", runtimeErr.Module, want)
+	}
+}
@@ -0,0 +1,385 @@
+package interpreter
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Max shift count of LSHIFT in the big number type, so that a single instruction can't exhaust the memory
+const MaxBigShift = 1 << 24
+
+var (
+	ErrorInvalidNumberType = errors.New("error: invalid number type")
+	ErrorShiftCount        = errors.New("error: invalid shift count")
+	ErrorDivisionByZero    = errors.New("error: division by zero")
+	ErrorOverflow          = errors.New("error: integer overflow")
+	ErrorValueType         = errors.New("error: operation not supported by the type of the value")
+	ErrorFloatConversion   = errors.New("error: infinite or NaN float can't be converted to an integer")
+)
+
+// Type of the integers held by the stack, which sets the range of the values and how arithmetic overflows
+type NumberType uint8
+
+const (
+	NumberInt32 NumberType = iota // 32 bits integers, wrapping around on overflow (default)
+	NumberInt64                   // 64 bits integers, wrapping around on overflow
+	NumberBig                     // integers of arbitrary precision, never overflowing
+)
+
+var numberTypeNames = [...]string{
+	NumberInt32: "int32",
+	NumberInt64: "int64",
+	NumberBig:   "big",
+}
+
+func (t NumberType) String() string {
+	if int(t) < len(numberTypeNames) {
+		return numberTypeNames[t]
+	}
+	return "unknown"
+}
+
+// Returns the NumberType with the given name: int32, int64 or big
+func ParseNumberType(name string) (NumberType, error) {
+	for t, n := range numberTypeNames {
+		if n == strings.ToLower(name) {
+			return NumberType(t), nil
+		}
+	}
+	return 0, ErrorInvalidNumberType
+}
+
+// Type of a value held by the stack
+type ValueType uint8
+
+const (
+	ValueInt   ValueType = iota // integer of the stack number type
+	ValueFloat                  // 64 bits floating point number
+)
+
+var valueTypeNames = [...]string{
+	ValueInt:   "int",
+	ValueFloat: "float",
+}
+
+func (t ValueType) String() string {
+	if int(t) < len(valueTypeNames) {
+		return valueTypeNames[t]
+	}
+	return "unknown"
+}
+
+/*
+ * Integer or float held by the stack, tagged with its type. Integers fitting in an int64 are stored in n,
+ * while big holds the values of the big number type that don't fit, so that small numbers are not allocated.
+ */
+type Value struct {
+	typ ValueType
+	n   int64
+	big *big.Int
+	f   float64
+}
+
+// Returns the value of the given integer
+func IntValue(n int64) Value {
+	return Value{n: n}
+}
+
+// Returns the value of the given float
+func FloatValue(f float64) Value {
+	return Value{typ: ValueFloat, f: f}
+}
+
+// Returns the value of the given big integer, stored in n if it fits
+func bigValue(b *big.Int) Value {
+	if b.IsInt64() {
+		return Value{n: b.Int64()}
+	}
+	return Value{big: b}
+}
+
+// Returns the type of the value
+func (v Value) Type() ValueType {
+	return v.typ
+}
+
+// Formats the value in base 10. Floats always have a decimal point or an exponent, so that they are not mistaken for integers.
+func (v Value) String() string {
+	switch {
+	case v.typ == ValueFloat:
+		s := strconv.FormatFloat(v.f, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eIN") {
+			s += ".0"
+		}
+		return s
+	case v.big != nil:
+		return v.big.String()
+	}
+	return strconv.FormatInt(v.n, 10)
+}
+
+// Returns the integer value as an int64. The second value is false if it doesn't fit or if it is a float, then the first one is meaningless.
+func (v Value) Int64() (int64, bool) {
+	return v.n, v.big == nil && v.typ == ValueInt
+}
+
+// Returns the float value. The second value is false if it is an integer.
+func (v Value) Float64() (float64, bool) {
+	return v.f, v.typ == ValueFloat
+}
+
+// Returns false for 0 and true for any other value
+func (v Value) Bool() bool {
+	if v.typ == ValueFloat {
+		return v.f != 0
+	}
+	return v.big != nil || v.n != 0
+}
+
+// Returns true if the value is a float NaN
+func (v Value) isNaN() bool {
+	return v.typ == ValueFloat && math.IsNaN(v.f)
+}
+
+/*
+ * Compares the value with w, returning -1, 0 or +1 as v is less than, equal to or greater than w.
+ * Integers and floats are compared by their exact value. As in sort.Float64Slice, NaN is equal to NaN and less than any other value.
+ */
+func (v Value) Cmp(w Value) int {
+	if v.typ == ValueFloat || w.typ == ValueFloat {
+		switch {
+		case v.isNaN() || w.isNaN():
+			return Btoi(w.isNaN()) - Btoi(v.isNaN())
+		case v.typ == ValueFloat && w.typ == ValueFloat:
+			return big.NewFloat(v.f).Cmp(big.NewFloat(w.f))
+		}
+		return v.toBigFloat().Cmp(w.toBigFloat())
+	}
+	if v.big == nil && w.big == nil {
+		switch {
+		case v.n < w.n:
+			return -1
+		case v.n > w.n:
+			return 1
+		}
+		return 0
+	}
+	return v.toBig().Cmp(w.toBig())
+}
+
+// Returns the value as a new big integer
+func (v Value) toBig() *big.Int {
+	if v.big != nil {
+		return new(big.Int).Set(v.big)
+	}
+	return big.NewInt(v.n)
+}
+
+// Returns the exact value as a new big float
+func (v Value) toBigFloat() *big.Float {
+	if v.typ == ValueFloat {
+		return big.NewFloat(v.f)
+	}
+	return new(big.Float).SetInt(v.toBig())
+}
+
+// Returns true if the value is in the range of the type. Floats are never out of range.
+func (t NumberType) holds(v Value) bool {
+	if v.typ == ValueFloat {
+		return true
+	}
+	switch t {
+	case NumberInt32:
+		return v.big == nil && v.n >= math.MinInt32 && v.n <= math.MaxInt32
+	case NumberInt64:
+		return v.big == nil
+	}
+	return true
+}
+
+// Returns the value wrapped around to the range of the type, as the result of an overflowing operation
+func (t NumberType) wrap(v Value) Value {
+	if v.typ == ValueFloat {
+		return v
+	}
+	if v.big != nil && t != NumberBig {
+		// The low 64 bits of the two's complement representation
+		v = Value{n: int64(new(big.Int).And(v.big, new(big.Int).SetUint64(math.MaxUint64)).Uint64())}
+	}
+	if t == NumberInt32 {
+		v.n = int64(int32(v.n))
+	}
+	return v
+}
+
+// Number of bits of the fixed size types, 0 for the big type
+func (t NumberType) bits() int64 {
+	switch t {
+	case NumberInt32:
+		return 32
+	case NumberInt64:
+		return 64
+	}
+	return 0
+}
+
+/*
+ * Operations on the integers of a number type. Results out of the range of the type wrap around,
+ * unless strict is set: then they are reported as ErrorOverflow, as the shift counts not smaller than the bits of the type.
+ * Float operands are reported as ErrorValueType.
+ */
+type arithmetic struct {
+	numbers NumberType
+	strict  bool
+}
+
+/*
+ * Applies a binary operation to a and b: the int64 one for the fixed size types, exact for int32 operands,
+ * or the big.Int one for the big type and to check the range of the int64 results in strict mode.
+ */
+func (m arithmetic) apply(a Value, b Value, small func(int64, int64) int64, large func(*big.Int, *big.Int, *big.Int) *big.Int) (Value, error) {
+	if a.typ != ValueInt || b.typ != ValueInt {
+		return Value{}, ErrorValueType
+	}
+	var result Value
+	if m.numbers == NumberBig || (m.strict && m.numbers == NumberInt64) {
+		result = bigValue(large(new(big.Int), a.toBig(), b.toBig()))
+	} else {
+		result = IntValue(small(a.n, b.n))
+	}
+	return m.fit(result)
+}
+
+// Returns the value wrapped around to the range of the number type, or ErrorOverflow in strict mode if it is out of range
+func (m arithmetic) fit(v Value) (Value, error) {
+	if m.strict && !m.numbers.holds(v) {
+		return Value{}, ErrorOverflow
+	}
+	return m.numbers.wrap(v), nil
+}
+
+func (m arithmetic) add(a Value, b Value) (Value, error) {
+	return m.apply(a, b, func(x int64, y int64) int64 { return x + y }, (*big.Int).Add)
+}
+
+func (m arithmetic) sub(a Value, b Value) (Value, error) {
+	return m.apply(a, b, func(x int64, y int64) int64 { return x - y }, (*big.Int).Sub)
+}
+
+func (m arithmetic) mul(a Value, b Value) (Value, error) {
+	return m.apply(a, b, func(x int64, y int64) int64 { return x * y }, (*big.Int).Mul)
+}
+
+// Returns a / b truncated toward zero
+func (m arithmetic) quo(a Value, b Value) (Value, error) {
+	if b.typ == ValueInt && !b.Bool() {
+		return Value{}, ErrorDivisionByZero
+	}
+	return m.apply(a, b, func(x int64, y int64) int64 { return x / y }, (*big.Int).Quo)
+}
+
+// Returns the remainder of a / b, with the sign of a
+func (m arithmetic) rem(a Value, b Value) (Value, error) {
+	if b.typ == ValueInt && !b.Bool() {
+		return Value{}, ErrorDivisionByZero
+	}
+	return m.apply(a, b, func(x int64, y int64) int64 { return x % y }, (*big.Int).Rem)
+}
+
+func (m arithmetic) and(a Value, b Value) (Value, error) {
+	return m.apply(a, b, func(x int64, y int64) int64 { return x & y }, (*big.Int).And)
+}
+
+func (m arithmetic) or(a Value, b Value) (Value, error) {
+	return m.apply(a, b, func(x int64, y int64) int64 { return x | y }, (*big.Int).Or)
+}
+
+func (m arithmetic) xor(a Value, b Value) (Value, error) {
+	return m.apply(a, b, func(x int64, y int64) int64 { return x ^ y }, (*big.Int).Xor)
+}
+
+// Returns the bitwise NOT of v, which never overflows
+func (m arithmetic) not(v Value) (Value, error) {
+	switch {
+	case v.typ != ValueInt:
+		return Value{}, ErrorValueType
+	case m.numbers == NumberBig:
+		return bigValue(new(big.Int).Not(v.toBig())), nil
+	}
+	return m.numbers.wrap(IntValue(^v.n)), nil
+}
+
+// Shifts v by count bits, to the left or to the right. Negative counts, and counts too big for the big type, are errors.
+func (m arithmetic) shift(v Value, count Value, left bool) (Value, error) {
+	if v.typ != ValueInt || count.typ != ValueInt {
+		return Value{}, ErrorValueType
+	}
+	n, ok := count.Int64()
+	if !ok || n < 0 {
+		return Value{}, ErrorShiftCount
+	}
+	if m.strict && m.numbers != NumberBig && n >= m.numbers.bits() {
+		return Value{}, ErrorOverflow
+	}
+	if !left {
+		if m.numbers == NumberBig {
+			return bigValue(new(big.Int).Rsh(v.toBig(), uint(n))), nil
+		}
+		return m.numbers.wrap(IntValue(v.n >> n)), nil
+	}
+	switch {
+	case m.numbers == NumberBig && n > MaxBigShift:
+		return Value{}, ErrorShiftCount
+	case m.numbers == NumberBig || (m.strict && m.numbers == NumberInt64):
+		return m.fit(bigValue(new(big.Int).Lsh(v.toBig(), uint(n))))
+	}
+	// Exact for int32 values shifted by less than 32 bits, the only counts allowed in strict mode
+	return m.fit(IntValue(v.n << n))
+}
+
+// Returns the integer as the nearest float
+func (m arithmetic) toFloat(v Value) (Value, error) {
+	switch {
+	case v.typ != ValueInt:
+		return Value{}, ErrorValueType
+	case v.big != nil:
+		f, _ := new(big.Float).SetInt(v.big).Float64()
+		return FloatValue(f), nil
+	}
+	return FloatValue(float64(v.n)), nil
+}
+
+// Returns the float truncated toward zero, wrapped around to the range of the number type or ErrorOverflow in strict mode
+func (m arithmetic) toInt(v Value) (Value, error) {
+	switch {
+	case v.typ != ValueFloat:
+		return Value{}, ErrorValueType
+	case math.IsNaN(v.f) || math.IsInf(v.f, 0):
+		return Value{}, ErrorFloatConversion
+	}
+	n, _ := big.NewFloat(v.f).Int(nil)
+	return m.fit(bigValue(n))
+}
+
+// Applies a binary operation to two floats. Results follow IEEE 754, e.g. dividing by zero gives an infinity or NaN.
+func applyFloat(a Value, b Value, op func(float64, float64) float64) (Value, error) {
+	if a.typ != ValueFloat || b.typ != ValueFloat {
+		return Value{}, ErrorValueType
+	}
+	return FloatValue(op(a.f, b.f)), nil
+}
+
+// Returns a random value between 0 included and n excluded. n must be greater than 0.
+func randomBelow(n Value) Value {
+	switch {
+	case n.big != nil:
+		return bigValue(new(big.Int).Rand(rand.New(rand.NewSource(rand.Int63())), n.big))
+	case n.n > math.MaxInt32:
+		return IntValue(rand.Int63n(n.n))
+	}
+	return IntValue(int64(rand.Int31n(int32(n.n))))
+}
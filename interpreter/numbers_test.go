@@ -0,0 +1,354 @@
+package interpreter
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// Returns the value of the given decimal number
+func parseTestValue(t *testing.T, s string) Value {
+	t.Helper()
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		t.Fatalf("invalid test number %q", s)
+	}
+	return bigValue(n)
+}
+
+func TestParseNumberType(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    NumberType
+		wantErr bool
+	}{
+		{name: "int32", want: NumberInt32},
+		{name: "INT64", want: NumberInt64},
+		{name: "Big", want: NumberBig},
+		{name: "int8", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseNumberType(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseNumberType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseNumberType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValue_String(t *testing.T) {
+	tests := []struct {
+		name string
+		v    Value
+		want string
+	}{
+		{name: "Integer", v: IntValue(-42), want: "-42"},
+		{name: "Big integer", v: parseTestValue(t, "100000000000000000000"), want: "100000000000000000000"},
+		{name: "Float", v: FloatValue(2.5), want: "2.5"},
+		{name: "Integral float", v: FloatValue(-3), want: "-3.0"},
+		{name: "Float with exponent", v: FloatValue(1e21), want: "1e+21"},
+		{name: "Infinity", v: FloatValue(math.Inf(1)), want: "+Inf"},
+		{name: "Not a number", v: FloatValue(math.NaN()), want: "NaN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.v.String(); got != tt.want {
+				t.Errorf("Value.String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValue_Cmp(t *testing.T) {
+	tests := []struct {
+		name string
+		a    Value
+		b    Value
+		want int
+	}{
+		{name: "Small values", a: IntValue(-3), b: IntValue(2), want: -1},
+		{name: "Equal small values", a: IntValue(7), b: IntValue(7), want: 0},
+		{name: "Big and small value", a: parseTestValue(t, "100000000000000000000"), b: IntValue(math.MaxInt64), want: 1},
+		{name: "Negative big value", a: parseTestValue(t, "-100000000000000000000"), b: IntValue(math.MinInt64), want: -1},
+		{name: "Equal big values", a: parseTestValue(t, "100000000000000000000"), b: parseTestValue(t, "100000000000000000000"), want: 0},
+		{name: "Floats", a: FloatValue(0.5), b: FloatValue(-0.5), want: 1},
+		{name: "Equal float and integer", a: FloatValue(3), b: IntValue(3), want: 0},
+		{name: "Integer not exactly converted to float", a: IntValue(math.MaxInt64), b: FloatValue(math.MaxInt64), want: -1},
+		{name: "Infinity and big value", a: FloatValue(math.Inf(1)), b: parseTestValue(t, "100000000000000000000"), want: 1},
+		{name: "NaN and negative infinity", a: FloatValue(math.NaN()), b: FloatValue(math.Inf(-1)), want: -1},
+		{name: "NaN and NaN", a: FloatValue(math.NaN()), b: FloatValue(math.NaN()), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Cmp(tt.b); got != tt.want {
+				t.Errorf("Value.Cmp() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	maxInt32, maxInt64 := IntValue(math.MaxInt32), IntValue(math.MaxInt64)
+	belowMinusTwoTo100 := parseTestValue(t, "-1267650600228229401496703205377")
+	int32s, int64s, bigs := arithmetic{numbers: NumberInt32}, arithmetic{numbers: NumberInt64}, arithmetic{numbers: NumberBig}
+	strict32, strict64, strictBig := arithmetic{numbers: NumberInt32, strict: true}, arithmetic{numbers: NumberInt64, strict: true}, arithmetic{numbers: NumberBig, strict: true}
+	tests := []struct {
+		name    string
+		got     func() (Value, error)
+		want    string
+		wantErr error
+	}{
+		{name: "int32 sum wraps around", got: func() (Value, error) { return int32s.add(maxInt32, IntValue(1)) }, want: "-2147483648"},
+		{name: "int64 sum", got: func() (Value, error) { return int64s.add(maxInt32, IntValue(1)) }, want: "2147483648"},
+		{name: "int64 sum wraps around", got: func() (Value, error) { return int64s.add(maxInt64, IntValue(1)) }, want: "-9223372036854775808"},
+		{name: "big sum", got: func() (Value, error) { return bigs.add(maxInt64, IntValue(1)) }, want: "9223372036854775808"},
+		{name: "big result fitting int64", got: func() (Value, error) { return bigs.sub(parseTestValue(t, "9223372036854775808"), IntValue(1)) }, want: "9223372036854775807"},
+		{name: "int32 product wraps around", got: func() (Value, error) { return int32s.mul(IntValue(65536), IntValue(65536)) }, want: "0"},
+		{name: "big product", got: func() (Value, error) { return bigs.mul(maxInt64, maxInt64) }, want: "85070591730234615847396907784232501249"},
+		{name: "int32 min divided by -1", got: func() (Value, error) { return int32s.quo(IntValue(math.MinInt32), IntValue(-1)) }, want: "-2147483648"},
+		{name: "big quotient truncated", got: func() (Value, error) { return bigs.quo(IntValue(-7), IntValue(2)) }, want: "-3"},
+		{name: "big remainder with the dividend sign", got: func() (Value, error) { return bigs.rem(IntValue(-7), IntValue(2)) }, want: "-1"},
+		{name: "big bitwise and of negative values", got: func() (Value, error) { return bigs.and(IntValue(-6), IntValue(7)) }, want: "2"},
+		{name: "int64 bitwise not", got: func() (Value, error) { return int64s.not(IntValue(5)) }, want: "-6"},
+		{name: "int32 left shift wraps around", got: func() (Value, error) { return int32s.shift(IntValue(3), IntValue(31), true) }, want: "-2147483648"},
+		{name: "int32 left shift by 32", got: func() (Value, error) { return int32s.shift(IntValue(1), IntValue(32), true) }, want: "0"},
+		{name: "int64 left shift by 32", got: func() (Value, error) { return int64s.shift(IntValue(1), IntValue(32), true) }, want: "4294967296"},
+		{name: "big left shift", got: func() (Value, error) { return bigs.shift(IntValue(1), IntValue(100), true) }, want: "1267650600228229401496703205376"},
+		{name: "int32 right shift keeps the sign", got: func() (Value, error) { return int32s.shift(IntValue(-8), IntValue(40), false) }, want: "-1"},
+		{name: "big right shift rounds down", got: func() (Value, error) { return bigs.shift(belowMinusTwoTo100, IntValue(100), false) }, want: "-2"},
+		{name: "Negative shift count", got: func() (Value, error) { return int64s.shift(IntValue(1), IntValue(-1), true) }, wantErr: ErrorShiftCount},
+		{name: "big shift count too big", got: func() (Value, error) { return bigs.shift(IntValue(1), IntValue(MaxBigShift+1), true) }, wantErr: ErrorShiftCount},
+		{name: "int32 division by zero", got: func() (Value, error) { return int32s.quo(IntValue(1), IntValue(0)) }, wantErr: ErrorDivisionByZero},
+		{name: "int64 modulus by zero", got: func() (Value, error) { return int64s.rem(IntValue(1), IntValue(0)) }, wantErr: ErrorDivisionByZero},
+		{name: "big division by zero", got: func() (Value, error) { return bigs.quo(maxInt64, IntValue(0)) }, wantErr: ErrorDivisionByZero},
+		{name: "Strict int32 sum", got: func() (Value, error) { return strict32.add(maxInt32, IntValue(-1)) }, want: "2147483646"},
+		{name: "Strict int32 sum overflow", got: func() (Value, error) { return strict32.add(maxInt32, IntValue(1)) }, wantErr: ErrorOverflow},
+		{name: "Strict int32 difference overflow", got: func() (Value, error) { return strict32.sub(IntValue(math.MinInt32), IntValue(1)) }, wantErr: ErrorOverflow},
+		{name: "Strict int32 product overflow", got: func() (Value, error) { return strict32.mul(IntValue(65536), IntValue(-32769)) }, wantErr: ErrorOverflow},
+		{name: "Strict int32 min divided by -1", got: func() (Value, error) { return strict32.quo(IntValue(math.MinInt32), IntValue(-1)) }, wantErr: ErrorOverflow},
+		{name: "Strict int32 left shift", got: func() (Value, error) { return strict32.shift(IntValue(1), IntValue(30), true) }, want: "1073741824"},
+		{name: "Strict int32 left shift overflow", got: func() (Value, error) { return strict32.shift(IntValue(1), IntValue(31), true) }, wantErr: ErrorOverflow},
+		{name: "Strict int32 right shift by 32", got: func() (Value, error) { return strict32.shift(IntValue(-1), IntValue(32), false) }, wantErr: ErrorOverflow},
+		{name: "Strict int64 product", got: func() (Value, error) { return strict64.mul(IntValue(math.MaxInt32), IntValue(math.MaxInt32)) }, want: "4611686014132420609"},
+		{name: "Strict int64 product overflow", got: func() (Value, error) { return strict64.mul(maxInt64, IntValue(2)) }, wantErr: ErrorOverflow},
+		{name: "Strict int64 left shift by 40", got: func() (Value, error) { return strict64.shift(IntValue(1), IntValue(40), true) }, want: "1099511627776"},
+		{name: "Strict int64 left shift by 64", got: func() (Value, error) { return strict64.shift(IntValue(0), IntValue(64), true) }, wantErr: ErrorOverflow},
+		{name: "Strict big product", got: func() (Value, error) { return strictBig.mul(maxInt64, maxInt64) }, want: "85070591730234615847396907784232501249"},
+		{name: "Float operand", got: func() (Value, error) { return int64s.add(IntValue(1), FloatValue(1)) }, wantErr: ErrorValueType},
+		{name: "Float divisor", got: func() (Value, error) { return int32s.quo(IntValue(1), FloatValue(0)) }, wantErr: ErrorValueType},
+		{name: "Bitwise not of a float", got: func() (Value, error) { return bigs.not(FloatValue(1)) }, wantErr: ErrorValueType},
+		{name: "Float shift count", got: func() (Value, error) { return int32s.shift(IntValue(1), FloatValue(1), true) }, wantErr: ErrorValueType},
+		{name: "Integer to float", got: func() (Value, error) { return int32s.toFloat(IntValue(-7)) }, want: "-7.0"},
+		{name: "Big integer to float", got: func() (Value, error) { return bigs.toFloat(parseTestValue(t, "100000000000000000000")) }, want: "1e+20"},
+		{name: "Float to float", got: func() (Value, error) { return int32s.toFloat(FloatValue(1)) }, wantErr: ErrorValueType},
+		{name: "Float truncated to integer", got: func() (Value, error) { return int32s.toInt(FloatValue(-2.9)) }, want: "-2"},
+		{name: "Float to int32 wraps around", got: func() (Value, error) { return int32s.toInt(FloatValue(4294967297)) }, want: "1"},
+		{name: "Float to big integer", got: func() (Value, error) { return bigs.toInt(FloatValue(1e20)) }, want: "100000000000000000000"},
+		{name: "Strict float to int32 overflow", got: func() (Value, error) { return strict32.toInt(FloatValue(4294967297)) }, wantErr: ErrorOverflow},
+		{name: "Infinity to integer", got: func() (Value, error) { return int64s.toInt(FloatValue(math.Inf(1))) }, wantErr: ErrorFloatConversion},
+		{name: "NaN to integer", got: func() (Value, error) { return bigs.toInt(FloatValue(math.NaN())) }, wantErr: ErrorFloatConversion},
+		{name: "Integer to integer", got: func() (Value, error) { return int64s.toInt(IntValue(1)) }, wantErr: ErrorValueType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.got()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("result = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpreter_RunNumberTypes(t *testing.T) {
+	// Prints 2^40, the product of two numbers out of the int32 range and their comparison
+	source := "push 1 push 40 lshift dup output_int literal 3000000 dup mul dup output_int lt output_int"
+	tests := []struct {
+		name    string
+		numbers NumberType
+		want    string
+	}{
+		{name: "int32", numbers: NumberInt32, want: "020435148801"},
+		{name: "int64", numbers: NumberInt64, want: "109951162777690000000000001"},
+		{name: "big", numbers: NumberBig, want: "109951162777690000000000001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := runTestSource(t, source, WithNumberType(tt.numbers))
+			if err != nil {
+				t.Fatalf("Interpreter.Run() error = %v", err)
+			}
+			if out != tt.want {
+				t.Errorf("Interpreter.Run() output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpreter_RunArithmeticErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		source  string
+		want    string
+		wantErr error
+	}{
+		{name: "Division by zero", source: "push 7 push 0 div output_int", wantErr: ErrorDivisionByZero},
+		{name: "Modulus by zero", opts: []Option{WithNumberType(NumberBig)}, source: "push 7 push 0 mod output_int", wantErr: ErrorDivisionByZero},
+		{name: "Overflow wrapping around", source: "literal 65536 dup mul push 1 sub output_int", want: "-1"},
+		{name: "Strict overflow", opts: []Option{WithStrictArithmetic(true)}, source: "push 3 output_int literal 65536 dup mul output_int", want: "3", wantErr: ErrorOverflow},
+		{name: "Strict int64", opts: []Option{WithStrictArithmetic(true), WithNumberType(NumberInt64)}, source: "literal 65536 dup mul output_int", want: "4294967296"},
+		{name: "Strict big", opts: []Option{WithStrictArithmetic(true), WithNumberType(NumberBig)}, source: "push 1 push 7 push 9 mul lshift output_int", want: "9223372036854775808"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := runTestSource(t, tt.source, tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Interpreter.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if out != tt.want {
+				t.Errorf("Interpreter.Run() output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpreter_RunFloats(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		source  string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "Float arithmetic",
+			source: "float_literal 1.5 float_literal 2 float_sum dup output_float float_literal 0.5 float_sub dup output_float float_literal 4 float_mul float_literal 8 float_div output_float",
+			want:   "3.53.01.5",
+		},
+		{
+			name:   "Division by zero",
+			source: "float_literal -1 float_literal 0 float_div output_float float_literal 0 dup float_div output_float",
+			want:   "-InfNaN",
+		},
+		{
+			name:   "Conversions",
+			source: "push 7 int_to_float push 2 int_to_float float_div dup output_float float_to_int output_int",
+			want:   "3.53",
+		},
+		{
+			name:   "Big conversions",
+			opts:   []Option{WithNumberType(NumberBig)},
+			source: "float_literal 1e30 float_to_int output_int",
+			want:   "1000000000000000019884624838656",
+		},
+		{
+			name:   "Comparisons",
+			source: "float_literal 2.5 push 2 gt output_int float_literal 2 push 2 eq output_int float_literal 0 dup float_div dup eq output_int",
+			want:   "110",
+		},
+		{
+			name:   "Input",
+			source: "input_float float_literal 2 float_mul output_float",
+			input:  "-1.25e2\n",
+			want:   "-250.0",
+		},
+		{
+			name:    "Invalid input",
+			source:  "input_float",
+			input:   "abc\n",
+			wantErr: ErrorInputScanning,
+		},
+		{
+			name:    "Integer arithmetic on a float",
+			source:  "float_literal 1 push 1 sum",
+			wantErr: ErrorValueType,
+		},
+		{
+			name:    "Float arithmetic on an integer",
+			source:  "float_literal 1 push 1 float_sum",
+			wantErr: ErrorValueType,
+		},
+		{
+			name:    "Output of a float as integer",
+			source:  "float_literal 1 output_int",
+			wantErr: ErrorValueType,
+		},
+		{
+			name:    "Output of an integer as float",
+			source:  "push 1 output_float",
+			wantErr: ErrorValueType,
+		},
+		{
+			name:    "Strict conversion overflow",
+			opts:    []Option{WithStrictArithmetic(true)},
+			source:  "float_literal 3e9 float_to_int",
+			wantErr: ErrorOverflow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := runTestSource(t, tt.source, append(tt.opts, WithInput(strings.NewReader(tt.input)))...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Interpreter.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if out != tt.want {
+				t.Errorf("Interpreter.Run() output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpreter_RunInputNumberTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		numbers NumberType
+		input   string
+		want    string
+		wantErr error
+	}{
+		{name: "int32", numbers: NumberInt32, input: "-2147483648\n", want: "-2147483647"},
+		{name: "Out of the int32 range", numbers: NumberInt32, input: "2147483648\n", wantErr: ErrorInputScanning},
+		{name: "int64", numbers: NumberInt64, input: "2147483648\n", want: "2147483649"},
+		{name: "Out of the int64 range", numbers: NumberInt64, input: "9223372036854775808\n", wantErr: ErrorInputScanning},
+		{name: "big", numbers: NumberBig, input: "-123456789012345678901234567890\n", want: "-123456789012345678901234567889"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := runTestSource(t, "input_int push 1 sum output_int", WithInput(strings.NewReader(tt.input)), WithNumberType(tt.numbers))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Interpreter.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if out != tt.want {
+				t.Errorf("Interpreter.Run() output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithNumberType(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithNumberType(NumberBig), WithMaxSize(5)},
+		{WithMaxSize(5), WithNumberType(NumberBig)},
+	} {
+		i, err := NewInterpreterWithOptions(opts...)
+		if err != nil {
+			t.Fatalf("NewInterpreterWithOptions() error = %v", err)
+		}
+		if i.stack.NumberType() != NumberBig || i.stack.maxSize != 5 {
+			t.Errorf("stack number type = %v, max size = %d, want big and 5", i.stack.NumberType(), i.stack.maxSize)
+		}
+	}
+	if _, err := NewInterpreterWithOptions(WithNumberType(NumberType(10))); err != ErrorInvalidNumberType {
+		t.Errorf("WithNumberType() error = %v, want %v", err, ErrorInvalidNumberType)
+	}
+}
@@ -0,0 +1,179 @@
+package interpreter
+
+import (
+	"bufio"
+	"io"
+	"time"
+)
+
+// Option configures an Interpreter built with NewInterpreterWithOptions
+type Option func(i *Interpreter) error
+
+// Enables or disables the step by step debugger
+func WithDebug(debug bool) Option {
+	return func(i *Interpreter) error {
+		i.isDebug = debug
+		return nil
+	}
+}
+
+// Sets the max size of the stack. -1 means no limit.
+func WithMaxSize(maxSize int) Option {
+	return func(i *Interpreter) error {
+		stack, err := NewStackOfType(maxSize, i.stack.numbers)
+		if err != nil {
+			return err
+		}
+		i.stack = stack
+		return nil
+	}
+}
+
+// Sets the side, in pixels, of each instruction square. AutoInstructionSize detects it from the loaded image.
+func WithInstructionSize(instructionSize int) Option {
+	return func(i *Interpreter) error {
+		i.instructionSize = instructionSize
+		return nil
+	}
+}
+
+// Sets the color codes of the operations
+func WithInstructionSet(set *InstructionSet) Option {
+	return func(i *Interpreter) error {
+		i.instructions = set
+		return nil
+	}
+}
+
+// Sets the stream read by INPUT_INT, INPUT_ASCII and by the debugger prompt
+func WithInput(r io.Reader) Option {
+	return func(i *Interpreter) error {
+		i.input = bufio.NewReader(r)
+		return nil
+	}
+}
+
+// Sets the stream written by OUTPUT_INT, OUTPUT_ASCII, OUTPUT and QUIT
+func WithOutput(w io.Writer) Option {
+	return func(i *Interpreter) error {
+		i.output = w
+		return nil
+	}
+}
+
+// Sets the stream written by the debugger
+func WithDebugOutput(w io.Writer) Option {
+	return func(i *Interpreter) error {
+		i.debugOutput = w
+		return nil
+	}
+}
+
+// Sets the max number of instructions executed by Run. 0 means no limit.
+func WithMaxSteps(maxSteps int) Option {
+	return func(i *Interpreter) error {
+		if maxSteps < 0 {
+			return ErrorInvalidMaxSteps
+		}
+		i.maxSteps = maxSteps
+		return nil
+	}
+}
+
+// Sets the max duration of Run. 0 means no limit.
+func WithTimeout(timeout time.Duration) Option {
+	return func(i *Interpreter) error {
+		if timeout < 0 {
+			return ErrorInvalidTimeout
+		}
+		i.timeout = timeout
+		return nil
+	}
+}
+
+// Confines the files opened by FILE_OPEN in the given directory. Paths built by the program are resolved from it.
+func WithFileRoot(dir string) Option {
+	return func(i *Interpreter) error {
+		root, err := resolveFileRoot(dir)
+		if err != nil {
+			return err
+		}
+		i.fileRoot = root
+		return nil
+	}
+}
+
+// Sets the operations allowed on the files opened by FILE_OPEN
+func WithFileAccess(access FileAccess) Option {
+	return func(i *Interpreter) error {
+		if int(access) >= len(fileAccessNames) {
+			return ErrorInvalidFileAccess
+		}
+		i.fileAccess = access
+		return nil
+	}
+}
+
+// Runs only the instructions inside the given region of the loaded image. Positions are relative to the origin of the region.
+func WithRegion(region Region) Option {
+	return func(i *Interpreter) error {
+		if region.Origin.X < 0 || region.Origin.Y < 0 || region.Width < 0 || region.Height < 0 {
+			return ErrorInvalidRegion
+		}
+		i.region = region
+		return nil
+	}
+}
+
+// Sets the order in which the instructions are executed
+func WithExecutionMode(mode ExecutionMode) Option {
+	return func(i *Interpreter) error {
+		if int(mode) >= len(executionModeNames) {
+			return ErrorInvalidExecutionMode
+		}
+		i.mode = mode
+		return nil
+	}
+}
+
+// Sets the max number of nested CALLs. DefaultMaxCallDepth is used by default.
+func WithMaxCallDepth(maxCallDepth int) Option {
+	return func(i *Interpreter) error {
+		if maxCallDepth <= 0 {
+			return ErrorInvalidMaxCallDepth
+		}
+		i.maxCallDepth = maxCallDepth
+		return nil
+	}
+}
+
+// Sets the number of cells of the heap, addressed by STORE and LOAD from 0. DefaultHeapSize is used by default.
+func WithHeapSize(size int) Option {
+	return func(i *Interpreter) error {
+		if size < 0 {
+			return ErrorInvalidHeapSize
+		}
+		i.heap = make([]Value, size)
+		return nil
+	}
+}
+
+// Sets the type of the integers held by the stack. NumberInt32 is used by default.
+func WithNumberType(numbers NumberType) Option {
+	return func(i *Interpreter) error {
+		stack, err := NewStackOfType(i.stack.maxSize, numbers)
+		if err != nil {
+			return err
+		}
+		i.stack = stack
+		return nil
+	}
+}
+
+// Enables or disables strict arithmetic: results out of the range of the number type are errors instead of wrapping around
+func WithStrictArithmetic(strict bool) Option {
+	return func(i *Interpreter) error {
+		i.strict = strict
+		return nil
+	}
+}
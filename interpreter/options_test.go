@@ -0,0 +1,100 @@
+package interpreter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewInterpreterWithOptions(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		debug           bool
+		program         []*Pixel
+		wantOutput      string
+		wantDebugOutput bool
+		wantErr         bool
+	}{
+		{
+			name:       "Int input to output",
+			input:      "42\n",
+			program:    []*Pixel{OPERATIONS["INPUT_INT"], OPERATIONS["OUTPUT_INT"]},
+			wantOutput: "42",
+		},
+		{
+			name:       "ASCII input to output",
+			input:      "vilmos\n",
+			program:    []*Pixel{OPERATIONS["INPUT_ASCII"], OPERATIONS["OUTPUT_ASCII"]},
+			wantOutput: "somliv",
+		},
+		{
+			name:       "Output all the stack and quit",
+			program:    []*Pixel{{R: 1, G: 0, B: 0}, {R: 2, G: 0, B: 0}, OPERATIONS["OUTPUT"], OPERATIONS["QUIT"]},
+			wantOutput: "21\n",
+		},
+		{
+			name:            "Debugger uses the given streams",
+			input:           "\n\n",
+			debug:           true,
+			program:         []*Pixel{{R: 7, G: 0, B: 0}, OPERATIONS["OUTPUT_INT"]},
+			wantOutput:      "7",
+			wantDebugOutput: true,
+		},
+		{
+			name:    "Invalid int input",
+			input:   "vilmos\n",
+			program: []*Pixel{OPERATIONS["INPUT_INT"]},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, debugOutput := &bytes.Buffer{}, &bytes.Buffer{}
+			i, err := NewInterpreterWithOptions(
+				WithDebug(tt.debug),
+				WithInput(strings.NewReader(tt.input)),
+				WithOutput(output),
+				WithDebugOutput(debugOutput),
+			)
+			if err != nil {
+				t.Fatalf("NewInterpreterWithOptions() error = %v", err)
+			}
+			loadTestProgram(i, tt.program...)
+
+			if _, err := i.Run(); (err != nil) != tt.wantErr {
+				t.Errorf("Interpreter.Run() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got := output.String(); !tt.wantErr && got != tt.wantOutput {
+				t.Errorf("Interpreter.Run() output = %q, want %q", got, tt.wantOutput)
+			}
+			if got := debugOutput.Len() > 0; got != tt.wantDebugOutput {
+				t.Errorf("Interpreter.Run() wrote debug output = %v, want %v", got, tt.wantDebugOutput)
+			}
+		})
+	}
+}
+
+func TestWithMaxSize(t *testing.T) {
+	if _, err := NewInterpreterWithOptions(WithMaxSize(-2)); err != ErrorInvalidMaxSize {
+		t.Errorf("NewInterpreterWithOptions() error = %v, want %v", err, ErrorInvalidMaxSize)
+	}
+	i, err := NewInterpreterWithOptions(WithMaxSize(10))
+	if err != nil {
+		t.Fatalf("NewInterpreterWithOptions() error = %v", err)
+	}
+	if i.stack.maxSize != 10 {
+		t.Errorf("stack max size = %d, want %d", i.stack.maxSize, 10)
+	}
+}
+
+func TestWithLimits(t *testing.T) {
+	if _, err := NewInterpreterWithOptions(WithMaxSteps(-1)); err != ErrorInvalidMaxSteps {
+		t.Errorf("WithMaxSteps(-1) error = %v, want %v", err, ErrorInvalidMaxSteps)
+	}
+	if _, err := NewInterpreterWithOptions(WithTimeout(-time.Second)); err != ErrorInvalidTimeout {
+		t.Errorf("WithTimeout(-1s) error = %v, want %v", err, ErrorInvalidTimeout)
+	}
+}
@@ -1,6 +1,49 @@
 package interpreter
 
-import "strconv"
+import (
+	"errors"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrorTransparentPixel    = errors.New("error: transparent instruction not allowed")
+	ErrorInvalidTransparency = errors.New("error: invalid transparency mode")
+)
+
+// Meaning of the fully transparent instruction squares
+type Transparency uint8
+
+const (
+	TransparencyNop    Transparency = iota // transparent squares are NOP instructions (default)
+	TransparencyOpaque                     // alpha is ignored and the color stored under the transparency is decoded
+	TransparencyError                      // transparent squares can't be compiled
+)
+
+var transparencyNames = [...]string{
+	TransparencyNop:    "nop",
+	TransparencyOpaque: "opaque",
+	TransparencyError:  "error",
+}
+
+func (t Transparency) String() string {
+	if int(t) < len(transparencyNames) {
+		return transparencyNames[t]
+	}
+	return "unknown"
+}
+
+// Returns the Transparency with the given name: nop, opaque or error
+func ParseTransparency(name string) (Transparency, error) {
+	for transparency, n := range transparencyNames {
+		if n == strings.ToLower(name) {
+			return Transparency(transparency), nil
+		}
+	}
+	return 0, ErrorInvalidTransparency
+}
 
 type Pixel struct {
 	R uint8
@@ -15,3 +58,13 @@ func (p *Pixel) String() string {
 func (p *Pixel) Equals(other Pixel) bool {
 	return (p.R == other.R) && (p.G == other.G) && (p.B == other.B)
 }
+
+/*
+ * Reads the pixel at the given coordinates together with its alpha value.
+ * Colors are not premultiplied by alpha, so a partially transparent pixel keeps its color code.
+ * A fully transparent pixel keeps the color stored in the image, if the format stores one, otherwise it is black.
+ */
+func readColor(img image.Image, x int, y int) (*Pixel, uint8) {
+	c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+	return &Pixel{R: c.R, G: c.G, B: c.B}, c.A
+}
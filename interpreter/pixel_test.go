@@ -1,6 +1,10 @@
 package interpreter
 
 import (
+	"errors"
+	"image"
+	"image/color"
+	"strings"
 	"testing"
 )
 
@@ -98,3 +102,117 @@ func TestPixel_Equals(t *testing.T) {
 		})
 	}
 }
+
+func Test_readColor(t *testing.T) {
+	tests := []struct {
+		name      string
+		c         color.Color
+		want      Pixel
+		wantAlpha uint8
+	}{
+		{name: "Opaque", c: color.RGBA{R: 200, G: 103, B: 40, A: 255}, want: Pixel{R: 200, G: 103, B: 40}, wantAlpha: 255},
+		{name: "Semi-transparent non premultiplied", c: color.NRGBA{R: 200, G: 103, B: 40, A: 128}, want: Pixel{R: 200, G: 103, B: 40}, wantAlpha: 128},
+		{name: "Transparent non premultiplied", c: color.NRGBA{R: 200, G: 103, B: 40, A: 0}, want: Pixel{R: 200, G: 103, B: 40}, wantAlpha: 0},
+		{name: "Transparent premultiplied", c: color.RGBA{}, want: Pixel{}, wantAlpha: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+			img.Set(0, 0, tt.c)
+			got, alpha := readColor(img, 0, 0)
+			if !got.Equals(tt.want) || alpha != tt.wantAlpha {
+				t.Errorf("readColor() = %v, %d, want %v, %d", got, alpha, &tt.want, tt.wantAlpha)
+			}
+		})
+	}
+}
+
+func TestParseTransparency(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Transparency
+		wantErr error
+	}{
+		{name: "nop", want: TransparencyNop},
+		{name: "Opaque", want: TransparencyOpaque},
+		{name: "ERROR", want: TransparencyError},
+		{name: "black", wantErr: ErrorInvalidTransparency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTransparency(tt.name)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseTransparency() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTransparency() = %v, want %v", got, tt.want)
+			}
+			if err == nil && got.String() != strings.ToLower(tt.name) {
+				t.Errorf("Transparency.String() = %v, want %v", got.String(), strings.ToLower(tt.name))
+			}
+		})
+	}
+	if got := Transparency(10).String(); got != "unknown" {
+		t.Errorf("Transparency.String() = %v, want unknown", got)
+	}
+}
+
+func TestCompile_Transparency(t *testing.T) {
+	set := NewInstructionSet()
+	sum, _ := set.Color("SUM")
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{R: sum.R, G: sum.G, B: sum.B, A: 255})
+	img.SetNRGBA(1, 0, color.NRGBA{R: sum.R, G: sum.G, B: sum.B, A: 0})
+
+	tests := []struct {
+		name         string
+		transparency Transparency
+		want         []Instruction
+		wantErr      error
+	}{
+		{name: "Nop", transparency: TransparencyNop, want: []Instruction{{Op: OpSum}, {Op: OpNop}}},
+		{name: "Opaque", transparency: TransparencyOpaque, want: []Instruction{{Op: OpSum}, {Op: OpSum}}},
+		{name: "Error", transparency: TransparencyError, wantErr: ErrorTransparentPixel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transparent, err := set.WithTransparency(tt.transparency)
+			if err != nil {
+				t.Fatalf("WithTransparency() error = %v", err)
+			}
+			program, err := Compile(img, transparent, 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Compile() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var compileErr *CompileError
+				if !errors.As(err, &compileErr) || compileErr.Pos != (image.Point{X: 1, Y: 0}) {
+					t.Errorf("Compile() error = %v, want *CompileError at (1, 0)", err)
+				}
+				return
+			}
+			if len(program.code) != len(tt.want) {
+				t.Fatalf("Compile() = %d instructions, want %d", len(program.code), len(tt.want))
+			}
+			for k, ins := range program.code {
+				if ins.Op != tt.want[k].Op {
+					t.Errorf("Compile() instruction %d = %v, want %v", k, ins.Op, tt.want[k].Op)
+				}
+			}
+		})
+	}
+}
+
+func TestInstructionSet_WithTransparency(t *testing.T) {
+	set := NewInstructionSet()
+	if _, err := set.WithTransparency(Transparency(10)); !errors.Is(err, ErrorInvalidTransparency) {
+		t.Errorf("WithTransparency() error = %v, want %v", err, ErrorInvalidTransparency)
+	}
+	transparent, err := set.WithTransparency(TransparencyError)
+	if err != nil {
+		t.Fatalf("WithTransparency() error = %v", err)
+	}
+	if set.transparency != TransparencyNop || transparent.transparency != TransparencyError {
+		t.Errorf("WithTransparency() must not modify the original set")
+	}
+}
@@ -0,0 +1,455 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"image"
+)
+
+var (
+	ErrorInvalidInstructionSize = errors.New("error: instruction size must be greater than 0")
+	ErrorNoProgram              = errors.New("error: no image loaded")
+	ErrorMissingLabelName       = errors.New("error: label and call must be followed by a value naming the routine")
+	ErrorDuplicateLabel         = errors.New("error: the same name is used by more than one label")
+	ErrorUndefinedLabel         = errors.New("error: call to an undefined label")
+)
+
+// Operation code of a decoded instruction
+type Opcode uint8
+
+const (
+	OpPush Opcode = iota
+	OpInputInt
+	OpOutputInt
+	OpSum
+	OpSub
+	OpDiv
+	OpMul
+	OpMod
+	OpRnd
+	OpAnd
+	OpOr
+	OpXor
+	OpNand
+	OpNot
+	OpBand
+	OpBor
+	OpBxor
+	OpBnot
+	OpLshift
+	OpRshift
+	OpInputASCII
+	OpOutputASCII
+	OpPop
+	OpSwap
+	OpCycle
+	OpRcycle
+	OpDup
+	OpReverse
+	OpQuit
+	OpOutput
+	OpWhile
+	OpWhileEnd
+	OpFileOpen
+	OpFileClose
+	OpNop
+	OpTurnRight
+	OpTurnLeft
+	OpReflect
+	OpTurnIf
+	OpEq
+	OpNeq
+	OpGt
+	OpLt
+	OpGte
+	OpLte
+	OpIf
+	OpElse
+	OpEndIf
+	OpLabel
+	OpCall
+	OpReturn
+	OpImport
+	OpOver
+	OpRot
+	OpPick
+	OpRoll
+	OpDepth
+	OpClear
+	OpLiteral
+	OpFloatLiteral
+	OpIntToFloat
+	OpFloatToInt
+	OpFloatSum
+	OpFloatSub
+	OpFloatMul
+	OpFloatDiv
+	OpInputFloat
+	OpOutputFloat
+	OpStore
+	OpLoad
+)
+
+// Names of the operation codes. Except for PUSH, they are the keys of OPERATIONS and of the config files.
+var opcodeNames = [...]string{
+	OpPush:         "PUSH",
+	OpInputInt:     "INPUT_INT",
+	OpOutputInt:    "OUTPUT_INT",
+	OpSum:          "SUM",
+	OpSub:          "SUB",
+	OpDiv:          "DIV",
+	OpMul:          "MUL",
+	OpMod:          "MOD",
+	OpRnd:          "RND",
+	OpAnd:          "AND",
+	OpOr:           "OR",
+	OpXor:          "XOR",
+	OpNand:         "NAND",
+	OpNot:          "NOT",
+	OpBand:         "BAND",
+	OpBor:          "BOR",
+	OpBxor:         "BXOR",
+	OpBnot:         "BNOT",
+	OpLshift:       "LSHIFT",
+	OpRshift:       "RSHIFT",
+	OpInputASCII:   "INPUT_ASCII",
+	OpOutputASCII:  "OUTPUT_ASCII",
+	OpPop:          "POP",
+	OpSwap:         "SWAP",
+	OpCycle:        "CYCLE",
+	OpRcycle:       "RCYCLE",
+	OpDup:          "DUP",
+	OpReverse:      "REVERSE",
+	OpQuit:         "QUIT",
+	OpOutput:       "OUTPUT",
+	OpWhile:        "WHILE",
+	OpWhileEnd:     "WHILE_END",
+	OpFileOpen:     "FILE_OPEN",
+	OpFileClose:    "FILE_CLOSE",
+	OpNop:          "NOP",
+	OpTurnRight:    "TURN_RIGHT",
+	OpTurnLeft:     "TURN_LEFT",
+	OpReflect:      "REFLECT",
+	OpTurnIf:       "TURN_IF",
+	OpEq:           "EQ",
+	OpNeq:          "NEQ",
+	OpGt:           "GT",
+	OpLt:           "LT",
+	OpGte:          "GTE",
+	OpLte:          "LTE",
+	OpIf:           "IF",
+	OpElse:         "ELSE",
+	OpEndIf:        "END_IF",
+	OpLabel:        "LABEL",
+	OpCall:         "CALL",
+	OpReturn:       "RETURN",
+	OpImport:       "IMPORT",
+	OpOver:         "OVER",
+	OpRot:          "ROT",
+	OpPick:         "PICK",
+	OpRoll:         "ROLL",
+	OpDepth:        "DEPTH",
+	OpClear:        "CLEAR",
+	OpLiteral:      "LITERAL",
+	OpFloatLiteral: "FLOAT_LITERAL",
+	OpIntToFloat:   "INT_TO_FLOAT",
+	OpFloatToInt:   "FLOAT_TO_INT",
+	OpFloatSum:     "FLOAT_SUM",
+	OpFloatSub:     "FLOAT_SUB",
+	OpFloatMul:     "FLOAT_MUL",
+	OpFloatDiv:     "FLOAT_DIV",
+	OpInputFloat:   "INPUT_FLOAT",
+	OpOutputFloat:  "OUTPUT_FLOAT",
+	OpStore:        "STORE",
+	OpLoad:         "LOAD",
+}
+
+// Operation codes indexed by their name
+var opcodesByName = func() map[string]Opcode {
+	opcodes := make(map[string]Opcode, len(opcodeNames))
+	for op, name := range opcodeNames {
+		opcodes[name] = Opcode(op)
+	}
+	return opcodes
+}()
+
+func (op Opcode) String() string {
+	if int(op) < len(opcodeNames) {
+		return opcodeNames[op]
+	}
+	return "UNKNOWN"
+}
+
+// Single decoded instruction. Value is the number pushed by PUSH and LITERAL instructions.
+type Instruction struct {
+	Op    Opcode
+	Value int32
+	Float float64 // value of a FLOAT_LITERAL, whose Value is the mantissa
+}
+
+// Error returned when an image can't be compiled into a program
+type CompileError struct {
+	Op  string
+	Pos image.Point
+	Err error
+}
+
+func (e *CompileError) Error() string {
+	if e.Op == "" {
+		return fmt.Sprintf("%s [position: (%d, %d)]", e.Err.Error(), e.Pos.X, e.Pos.Y)
+	}
+	return fmt.Sprintf("%s [op: %s, position: (%d, %d)]", e.Err.Error(), e.Op, e.Pos.X, e.Pos.Y)
+}
+
+func (e *CompileError) Unwrap() error {
+	return e.Err
+}
+
+/*
+ * Program decoded from an image.
+ * Instructions are stored in execution order: the image grid is read row by row
+ * taking the upper-left pixel of each instruction square.
+ * The instruction following a LITERAL is its value: it is stored as a PUSH of the same value, skipped by the LITERAL.
+ * jumps holds, for each block instruction (WHILE, WHILE_END, IF, ELSE and END_IF), the index of the matching instruction
+ * and, for each CALL, the index of the called LABEL or -1 if the label is defined by an imported module.
+ * labels holds the index of each LABEL by name. path is the path of the image, if known, used to resolve the imported modules.
+ */
+type Program struct {
+	code            []Instruction
+	jumps           []int
+	columns         int
+	rows            int
+	instructionSize int
+	labels          map[int32]int
+	path            string
+}
+
+/*
+ * Decodes the given image into a program. Each instruction is a square of instructionSize pixels per side
+ * and its color is decoded with the given instruction set. Fully transparent squares follow the transparency of the set.
+ * With AutoInstructionSize the size is detected from the image, whose squares must be uniformly colored.
+ */
+func Compile(img image.Image, set *InstructionSet, instructionSize int) (*Program, error) {
+	if instructionSize == AutoInstructionSize {
+		size, err := DetectInstructionSize(img)
+		if err != nil {
+			return nil, err
+		}
+		instructionSize = size
+	}
+	if instructionSize <= 0 {
+		return nil, ErrorInvalidInstructionSize
+	}
+	width, height := img.Bounds().Max.X, img.Bounds().Max.Y
+	program := &Program{
+		columns:         (width + instructionSize - 1) / instructionSize,
+		rows:            (height + instructionSize - 1) / instructionSize,
+		instructionSize: instructionSize,
+	}
+	program.code = make([]Instruction, 0, program.columns*program.rows)
+	literal, values := 0, 0 // index of the last LITERAL or FLOAT_LITERAL and number of its value pixels still to read
+	for y := 0; y < height; y += instructionSize {
+		for x := 0; x < width; x += instructionSize {
+			p, alpha := readColor(img, x, y)
+			if values > 0 {
+				// The values of a LITERAL are read from the color bits, whatever operation or transparency they match
+				program.code = append(program.code, Instruction{Op: OpPush, Value: literalValue(p)})
+				if values--; values == 0 {
+					program.setLiteral(literal)
+				}
+				continue
+			}
+			if alpha == 0 && set.transparency != TransparencyOpaque {
+				if set.transparency == TransparencyError {
+					return nil, &CompileError{Pos: image.Point{X: x, Y: y}, Err: ErrorTransparentPixel}
+				}
+				program.code = append(program.code, Instruction{Op: OpNop})
+				continue
+			}
+			ins, err := set.Decode(p)
+			if err != nil {
+				var compileErr *CompileError
+				if errors.As(err, &compileErr) {
+					compileErr.Pos = image.Point{X: x, Y: y}
+				}
+				return nil, err
+			}
+			program.code = append(program.code, ins)
+			literal, values = len(program.code)-1, literalValues(ins.Op)
+		}
+	}
+	if values > 0 {
+		return nil, &CompileError{Op: program.code[literal].Op.String(), Pos: program.Position(literal), Err: ErrorMissingLiteralValue}
+	}
+	if err := program.resolveJumps(); err != nil {
+		return nil, err
+	}
+	if err := program.resolveLabels(); err != nil {
+		return nil, err
+	}
+	return program, nil
+}
+
+// Stores in the LITERAL or FLOAT_LITERAL at the given index the value read from the pixels following it
+func (p *Program) setLiteral(index int) {
+	ins := &p.code[index]
+	ins.Value = p.code[index+1].Value
+	if ins.Op == OpFloatLiteral {
+		ins.Float = floatLiteral(ins.Value, p.code[index+2].Value)
+	}
+}
+
+/*
+ * Matches each WHILE with its WHILE_END and each IF with its optional ELSE and its END_IF. Blocks can be nested
+ * but not interleaved: unmatched or interleaved block instructions are reported with their position.
+ * jumps of an IF points to its ELSE, if any, or to its END_IF, while jumps of an ELSE points to the END_IF
+ * and jumps of an END_IF points back to the IF.
+ */
+func (p *Program) resolveJumps() error {
+	p.jumps = make([]int, len(p.code))
+	var open []int
+	for index, ins := range p.code {
+		switch ins.Op {
+		case OpWhile, OpIf:
+			open = append(open, index)
+		case OpWhileEnd:
+			if len(open) == 0 {
+				return &CompileError{Op: ins.Op.String(), Pos: p.Position(index), Err: ErrorMissingStartLoop}
+			}
+			start := open[len(open)-1]
+			if p.code[start].Op != OpWhile {
+				return p.unclosedBlock(start)
+			}
+			open = open[:len(open)-1]
+			p.jumps[start], p.jumps[index] = index, start
+		case OpElse, OpEndIf:
+			if len(open) == 0 {
+				return &CompileError{Op: ins.Op.String(), Pos: p.Position(index), Err: ErrorMissingStartIf}
+			}
+			start := open[len(open)-1]
+			switch {
+			case p.code[start].Op == OpWhile:
+				return p.unclosedBlock(start)
+			case ins.Op == OpElse && p.code[start].Op == OpElse:
+				return &CompileError{Op: ins.Op.String(), Pos: p.Position(index), Err: ErrorDuplicateElse}
+			case ins.Op == OpElse:
+				// The ELSE takes the place of its IF, whose index is kept until the END_IF is found
+				p.jumps[start], p.jumps[index] = index, start
+				open[len(open)-1] = index
+			default:
+				ifIndex := start
+				if p.code[start].Op == OpElse {
+					ifIndex = p.jumps[start]
+				}
+				open = open[:len(open)-1]
+				p.jumps[start], p.jumps[index] = index, ifIndex
+			}
+		}
+	}
+	if len(open) != 0 {
+		return p.unclosedBlock(open[len(open)-1])
+	}
+	return nil
+}
+
+/*
+ * Matches each CALL with the LABEL having the same name. The name of a LABEL or of a CALL is the value pushed by
+ * the following instruction, in reading order, which is part of the LABEL or of the CALL and is not executed.
+ * A program with IMPORT instructions can call labels defined by the imported modules, which are resolved when called.
+ */
+func (p *Program) resolveLabels() error {
+	labels := make(map[int32]int)
+	var calls []int
+	imports := false
+	for index, ins := range p.code {
+		if ins.Op == OpImport {
+			imports = true
+		}
+		if ins.Op != OpLabel && ins.Op != OpCall {
+			continue
+		}
+		if index+1 >= len(p.code) || p.code[index+1].Op != OpPush {
+			return &CompileError{Op: ins.Op.String(), Pos: p.Position(index), Err: ErrorMissingLabelName}
+		}
+		if ins.Op == OpCall {
+			calls = append(calls, index)
+			continue
+		}
+		name := p.code[index+1].Value
+		if _, ok := labels[name]; ok {
+			return &CompileError{Op: ins.Op.String(), Pos: p.Position(index), Err: ErrorDuplicateLabel}
+		}
+		labels[name] = index
+	}
+	for _, index := range calls {
+		label, ok := labels[p.code[index+1].Value]
+		if !ok && !imports {
+			return &CompileError{Op: p.code[index].Op.String(), Pos: p.Position(index), Err: ErrorUndefinedLabel}
+		}
+		if !ok {
+			label = -1
+		}
+		p.jumps[index] = label
+	}
+	p.labels = labels
+	return nil
+}
+
+// Returns the error for the block opened at the given index and never closed
+func (p *Program) unclosedBlock(start int) error {
+	err := ErrorMissingEndIf
+	if p.code[start].Op == OpWhile {
+		err = ErrorMissingEndLoop
+	}
+	return &CompileError{Op: p.code[start].Op.String(), Pos: p.Position(start), Err: err}
+}
+
+// Returns the number of instructions of the program
+func (p *Program) Len() int {
+	return len(p.code)
+}
+
+// Returns the side, in pixels, of the instruction squares
+func (p *Program) InstructionSize() int {
+	return p.instructionSize
+}
+
+// Returns the instruction at the given index
+func (p *Program) Instruction(index int) Instruction {
+	return p.code[index]
+}
+
+// Returns the coordinates, in pixels, of the upper-left corner of the instruction at the given index
+func (p *Program) Position(index int) image.Point {
+	return image.Point{
+		X: (index % p.columns) * p.instructionSize,
+		Y: (index / p.columns) * p.instructionSize,
+	}
+}
+
+// Returns the index of the instruction containing the given pixel. The second value is false if the pixel is outside the program.
+func (p *Program) Index(pos image.Point) (int, bool) {
+	col, row := pos.X/p.instructionSize, pos.Y/p.instructionSize
+	if pos.X < 0 || pos.Y < 0 || col >= p.columns || row >= p.rows {
+		return 0, false
+	}
+	index := row*p.columns + col
+	if index >= len(p.code) {
+		return 0, false
+	}
+	return index, true
+}
+
+// Returns the index of the instruction next to the given one in the given direction. The second value is false at the edges of the program.
+func (p *Program) Neighbor(index int, direction Direction) (int, bool) {
+	dx, dy := direction.offset()
+	col, row := index%p.columns+dx, index/p.columns+dy
+	if col < 0 || row < 0 || col >= p.columns || row >= p.rows {
+		return 0, false
+	}
+	next := row*p.columns + col
+	if next >= len(p.code) {
+		return 0, false
+	}
+	return next, true
+}
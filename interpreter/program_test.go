@@ -0,0 +1,267 @@
+package interpreter
+
+import (
+	"image"
+	"image/color"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name            string
+		img             image.Image
+		instructionSize int
+		want            []Instruction
+		wantColumns     int
+		wantRows        int
+		wantErr         error
+	}{
+		{
+			name:            "Image with big instructions",
+			img:             img,
+			instructionSize: 200,
+			want: []Instruction{
+				{Op: OpPush, Value: 0}, {Op: OpPush, Value: 108}, {Op: OpPush, Value: 111}, {Op: OpPush, Value: 97}, {Op: OpPush, Value: 100},
+				{Op: OpPush, Value: 105}, {Op: OpPush, Value: 110}, {Op: OpPush, Value: 103}, {Op: OpPush, Value: 32}, {Op: OpPush, Value: 105},
+				{Op: OpPush, Value: 109}, {Op: OpPush, Value: 97}, {Op: OpPush, Value: 103}, {Op: OpPush, Value: 101}, {Op: OpPush, Value: 32},
+				{Op: OpPush, Value: 116}, {Op: OpPush, Value: 101}, {Op: OpPush, Value: 115}, {Op: OpPush, Value: 116}, {Op: OpOutputASCII},
+			},
+			wantColumns: 5,
+			wantRows:    4,
+		},
+		{
+			name:            "Partial instructions on the edges",
+			img:             newTestImage(3, 1, OPERATIONS["SUM"], &Pixel{R: 2}, OPERATIONS["POP"]),
+			instructionSize: 2,
+			want:            []Instruction{{Op: OpSum}, {Op: OpPop}},
+			wantColumns:     2,
+			wantRows:        1,
+		},
+		{
+			name:            "Invalid instruction size",
+			img:             img,
+			instructionSize: 0,
+			wantErr:         ErrorInvalidInstructionSize,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compile(tt.img, NewInstructionSet(), tt.instructionSize)
+			if err != tt.wantErr {
+				t.Fatalf("Compile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got.code, tt.want) {
+				t.Errorf("Compile() code = %v, want %v", got.code, tt.want)
+			}
+			if got.columns != tt.wantColumns || got.rows != tt.wantRows {
+				t.Errorf("Compile() grid = %dx%d, want %dx%d", got.columns, got.rows, tt.wantColumns, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestCompile_Jumps(t *testing.T) {
+	while, end := OPERATIONS["WHILE"], OPERATIONS["WHILE_END"]
+	ifOp, elseOp, endIf := OPERATIONS["IF"], OPERATIONS["ELSE"], OPERATIONS["END_IF"]
+	tests := []struct {
+		name    string
+		img     image.Image
+		want    []int
+		wantErr *CompileError
+	}{
+		{
+			name: "Nested loops",
+			img:  newTestImage(6, 1, while, &Pixel{R: 1}, while, end, end, &Pixel{R: 1}),
+			want: []int{4, 0, 3, 2, 0, 0},
+		},
+		{
+			name: "Loops on different rows",
+			img:  newTestImage(2, 2, while, &Pixel{R: 1}, &Pixel{R: 1}, end),
+			want: []int{3, 0, 0, 0},
+		},
+		{
+			name:    "Missing end loop",
+			img:     newTestImage(3, 2, &Pixel{R: 1}, while, while, end, &Pixel{R: 1}, &Pixel{R: 1}),
+			wantErr: &CompileError{Op: "WHILE", Pos: image.Point{X: 1, Y: 0}, Err: ErrorMissingEndLoop},
+		},
+		{
+			name:    "Missing start loop",
+			img:     newTestImage(2, 2, while, end, &Pixel{R: 1}, end),
+			wantErr: &CompileError{Op: "WHILE_END", Pos: image.Point{X: 1, Y: 1}, Err: ErrorMissingStartLoop},
+		},
+		{
+			name: "If with else",
+			img:  newTestImage(5, 1, ifOp, &Pixel{R: 1}, elseOp, &Pixel{R: 2}, endIf),
+			want: []int{2, 0, 4, 0, 0},
+		},
+		{
+			name:    "Nested if in a loop",
+			img:     newTestImage(6, 1, while, ifOp, elseOp, ifOp, endIf, endIf),
+			wantErr: &CompileError{Op: "WHILE", Pos: image.Point{X: 0, Y: 0}, Err: ErrorMissingEndLoop},
+		},
+		{
+			name: "If without else in a loop",
+			img:  newTestImage(4, 1, while, ifOp, endIf, end),
+			want: []int{3, 2, 1, 0},
+		},
+		{
+			name: "Nested ifs",
+			img:  newTestImage(6, 1, ifOp, ifOp, endIf, elseOp, endIf, &Pixel{R: 1}),
+			want: []int{3, 2, 1, 4, 0, 0},
+		},
+		{
+			name:    "Missing end if",
+			img:     newTestImage(3, 1, &Pixel{R: 1}, ifOp, elseOp),
+			wantErr: &CompileError{Op: "ELSE", Pos: image.Point{X: 2, Y: 0}, Err: ErrorMissingEndIf},
+		},
+		{
+			name:    "Missing start if",
+			img:     newTestImage(2, 1, &Pixel{R: 1}, endIf),
+			wantErr: &CompileError{Op: "END_IF", Pos: image.Point{X: 1, Y: 0}, Err: ErrorMissingStartIf},
+		},
+		{
+			name:    "Duplicate else",
+			img:     newTestImage(4, 1, ifOp, elseOp, elseOp, endIf),
+			wantErr: &CompileError{Op: "ELSE", Pos: image.Point{X: 2, Y: 0}, Err: ErrorDuplicateElse},
+		},
+		{
+			name:    "Interleaved blocks",
+			img:     newTestImage(4, 1, while, ifOp, end, endIf),
+			wantErr: &CompileError{Op: "IF", Pos: image.Point{X: 1, Y: 0}, Err: ErrorMissingEndIf},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compile(tt.img, NewInstructionSet(), 1)
+			if tt.wantErr != nil {
+				if !reflect.DeepEqual(err, tt.wantErr) {
+					t.Errorf("Compile() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.jumps, tt.want) {
+				t.Errorf("Compile() jumps = %v, want %v", got.jumps, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgram_Position(t *testing.T) {
+	tests := []struct {
+		name  string
+		index int
+		want  image.Point
+	}{
+		{name: "First instruction", index: 0, want: image.Point{X: 0, Y: 0}},
+		{name: "Same row", index: 3, want: image.Point{X: 600, Y: 0}},
+		{name: "Next row", index: 7, want: image.Point{X: 400, Y: 200}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testProgram.Position(tt.index); got != tt.want {
+				t.Errorf("Program.Position() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpcode_String(t *testing.T) {
+	for name := range OPERATIONS {
+		if got := opcodesByName[name].String(); got != name {
+			t.Errorf("Opcode.String() = %s, want %s", got, name)
+		}
+	}
+	if got := OpPush.String(); got != "PUSH" {
+		t.Errorf("Opcode.String() = %s, want PUSH", got)
+	}
+}
+
+// Builds an image of the given size with a 1px instruction for each pixel, row by row
+func newTestImage(width int, height int, pixels ...*Pixel) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for index, p := range pixels {
+		img.Set(index%width, index/width, color.RGBA{R: p.R, G: p.G, B: p.B, A: 255})
+	}
+	return img
+}
+
+// Builds a square program that keeps pushing, summing and popping numbers
+func newBenchmarkImage(side int) *image.RGBA {
+	body := []*Pixel{{R: 1}, {R: 2}, OPERATIONS["SUM"], OPERATIONS["POP"]}
+	pixels := make([]*Pixel, side*side)
+	for index := range pixels {
+		pixels[index] = body[index%len(body)]
+	}
+	return newTestImage(side, side, pixels...)
+}
+
+// Operations compared with each pixel by the interpreter before compiling programs, in the order of its switch
+var stringDispatchOrder = []string{
+	"INPUT_INT", "INPUT_ASCII", "OUTPUT_INT", "OUTPUT_ASCII", "SUM", "SUB", "DIV", "MUL", "MOD", "RND",
+	"AND", "OR", "XOR", "NAND", "NOT", "BAND", "BOR", "BXOR", "BNOT", "LSHIFT", "RSHIFT",
+	"POP", "SWAP", "CYCLE", "RCYCLE", "DUP", "REVERSE", "QUIT", "OUTPUT", "WHILE", "WHILE_END", "FILE_OPEN", "FILE_CLOSE",
+}
+
+// Decodes the pixel as the interpreter did before compiling programs, formatting the color of each operation until one matches
+func decodeByString(p *Pixel) Instruction {
+	hex := p.String()
+	for _, name := range stringDispatchOrder {
+		if hex == OPERATIONS[name].String() {
+			return Instruction{Op: opcodesByName[name]}
+		}
+	}
+	return Instruction{Op: OpPush, Value: int32(p.R) + int32(p.G) + int32(p.B)}
+}
+
+// Executes the image reading and decoding each pixel when it is reached, as the interpreter did before compiling programs
+func BenchmarkRun_DecodeEachStep(b *testing.B) {
+	img := newBenchmarkImage(256)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		i, _ := NewInterpreterWithOptions(WithOutput(io.Discard))
+		for y := 0; y < 256; y++ {
+			for x := 0; x < 256; x++ {
+				if _, err := processInstruction(decodeByString(readPixel(img, x, y)), i); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+}
+
+// Compiles and executes the image, so that it pays for decoding the pixels as BenchmarkRun_DecodeEachStep does
+func BenchmarkRun_Compiled(b *testing.B) {
+	img := newBenchmarkImage(256)
+	set := NewInstructionSet()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		program, err := Compile(img, set, 1)
+		if err != nil {
+			b.Fatal(err)
+		}
+		i, _ := NewInterpreterWithOptions(WithOutput(io.Discard))
+		i.image, i.program = img, program
+		if _, err := i.Run(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCompile(b *testing.B) {
+	img := newBenchmarkImage(256)
+	set := NewInstructionSet()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := Compile(img, set, 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
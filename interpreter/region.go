@@ -0,0 +1,91 @@
+package interpreter
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+var ErrorInvalidRegion = errors.New("error: region must be written as X,Y or X,Y,WIDTH,HEIGHT and must be inside the image")
+
+/*
+ * Part of an image holding a program, e.g. the inside of a bordered canvas.
+ * Origin is the upper-left pixel of the region, which is the first instruction executed.
+ * A Width or Height of 0 extends the region up to the right or bottom edge of the image,
+ * so the zero Region is the whole image.
+ */
+type Region struct {
+	Origin image.Point
+	Width  int
+	Height int
+}
+
+// Parses a region written as "X,Y" or "X,Y,WIDTH,HEIGHT"
+func ParseRegion(s string) (Region, error) {
+	fields := strings.Split(s, ",")
+	if len(fields) != 2 && len(fields) != 4 {
+		return Region{}, ErrorInvalidRegion
+	}
+	values := make([]int, 4)
+	for index, field := range fields {
+		value, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || value < 0 {
+			return Region{}, ErrorInvalidRegion
+		}
+		values[index] = value
+	}
+	return Region{Origin: image.Point{X: values[0], Y: values[1]}, Width: values[2], Height: values[3]}, nil
+}
+
+// Returns the rectangle of the given image bounds covered by the region
+func (r Region) Rect(bounds image.Rectangle) (image.Rectangle, error) {
+	if r.Origin.X < 0 || r.Origin.Y < 0 || r.Width < 0 || r.Height < 0 {
+		return image.Rectangle{}, ErrorInvalidRegion
+	}
+	rect := image.Rectangle{Min: r.Origin, Max: bounds.Max}
+	if r.Width > 0 {
+		rect.Max.X = r.Origin.X + r.Width
+	}
+	if r.Height > 0 {
+		rect.Max.Y = r.Origin.Y + r.Height
+	}
+	if rect.Empty() || !rect.In(bounds) {
+		return image.Rectangle{}, ErrorInvalidRegion
+	}
+	return rect, nil
+}
+
+/*
+ * Returns the part of the image inside the region. The upper-left pixel of the returned image
+ * is the origin of the region, so the positions of its instructions are relative to the origin.
+ */
+func Crop(img image.Image, region Region) (image.Image, error) {
+	rect, err := region.Rect(img.Bounds())
+	if err != nil {
+		return nil, err
+	}
+	return &croppedImage{img: img, rect: rect}, nil
+}
+
+// Image showing the rect part of img, moved to the origin
+type croppedImage struct {
+	img  image.Image
+	rect image.Rectangle
+}
+
+func (c *croppedImage) ColorModel() color.Model {
+	return c.img.ColorModel()
+}
+
+func (c *croppedImage) Bounds() image.Rectangle {
+	return image.Rect(0, 0, c.rect.Dx(), c.rect.Dy())
+}
+
+func (c *croppedImage) At(x int, y int) color.Color {
+	if !(image.Point{X: x, Y: y}.In(c.Bounds())) {
+		return color.Transparent
+	}
+	return c.img.At(c.rect.Min.X+x, c.rect.Min.Y+y)
+}
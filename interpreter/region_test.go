@@ -0,0 +1,153 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestParseRegion(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    Region
+		wantErr error
+	}{
+		{name: "Origin", s: "3,4", want: Region{Origin: image.Point{X: 3, Y: 4}}},
+		{name: "Origin and size", s: "3, 4, 10, 20", want: Region{Origin: image.Point{X: 3, Y: 4}, Width: 10, Height: 20}},
+		{name: "Missing coordinate", s: "3", wantErr: ErrorInvalidRegion},
+		{name: "Missing height", s: "3,4,10", wantErr: ErrorInvalidRegion},
+		{name: "Negative", s: "-1,4", wantErr: ErrorInvalidRegion},
+		{name: "Not a number", s: "a,4", wantErr: ErrorInvalidRegion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRegion(tt.s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseRegion() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRegion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegion_Rect(t *testing.T) {
+	bounds := image.Rect(0, 0, 10, 8)
+	tests := []struct {
+		name    string
+		region  Region
+		want    image.Rectangle
+		wantErr error
+	}{
+		{name: "Whole image", region: Region{}, want: bounds},
+		{name: "Origin", region: Region{Origin: image.Point{X: 2, Y: 3}}, want: image.Rect(2, 3, 10, 8)},
+		{name: "Origin and size", region: Region{Origin: image.Point{X: 2, Y: 3}, Width: 4, Height: 2}, want: image.Rect(2, 3, 6, 5)},
+		{name: "Origin outside", region: Region{Origin: image.Point{X: 10, Y: 0}}, wantErr: ErrorInvalidRegion},
+		{name: "Too wide", region: Region{Origin: image.Point{X: 2, Y: 3}, Width: 9}, wantErr: ErrorInvalidRegion},
+		{name: "Negative size", region: Region{Height: -1}, wantErr: ErrorInvalidRegion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.region.Rect(bounds)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Region.Rect() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Region.Rect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Draws the program on a canvas with a border of the given width painted with the border color
+func borderedProgram(border int, borderColor Pixel, program ...*Pixel) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, len(program)+2*border, 1+2*border))
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			img.Set(x, y, color.NRGBA{R: borderColor.R, G: borderColor.G, B: borderColor.B, A: 255})
+		}
+	}
+	for x, p := range program {
+		img.Set(border+x, border, color.NRGBA{R: p.R, G: p.G, B: p.B, A: 255})
+	}
+	return img
+}
+
+func TestCrop(t *testing.T) {
+	img := borderedProgram(2, Pixel{R: 200}, &Pixel{R: 1}, &Pixel{R: 2})
+	cropped, err := Crop(img, Region{Origin: image.Point{X: 2, Y: 2}, Width: 2, Height: 1})
+	if err != nil {
+		t.Fatalf("Crop() error = %v", err)
+	}
+	if got := cropped.Bounds(); got != image.Rect(0, 0, 2, 1) {
+		t.Errorf("Crop() bounds = %v, want %v", got, image.Rect(0, 0, 2, 1))
+	}
+	for x, want := range []Pixel{{R: 1}, {R: 2}} {
+		if got, _ := readColor(cropped, x, 0); !got.Equals(want) {
+			t.Errorf("Crop() pixel (%d, 0) = %v, want %v", x, got, &want)
+		}
+	}
+	if _, err := Crop(img, Region{Origin: image.Point{X: 7, Y: 0}}); err != ErrorInvalidRegion {
+		t.Errorf("Crop() error = %v, want %v", err, ErrorInvalidRegion)
+	}
+}
+
+func TestInterpreter_LoadImageWithRegion(t *testing.T) {
+	program := []*Pixel{{R: 1}, {R: 2}, OPERATIONS["SUM"], OPERATIONS["NOP"], OPERATIONS["OUTPUT_INT"]}
+	background := Pixel{R: 12, G: 34, B: 56}
+	set, err := NewInstructionSet().WithBackground(background)
+	if err != nil {
+		t.Fatalf("InstructionSet.WithBackground() error = %v", err)
+	}
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantStack int
+	}{
+		{
+			name: "Region inside the border",
+			opts: []Option{WithRegion(Region{Origin: image.Point{X: 3, Y: 3}, Width: len(program), Height: 1})},
+		},
+		{
+			name:      "Border pushing its color",
+			wantStack: 11*7 - len(program),
+		},
+		{
+			name: "Background border",
+			opts: []Option{WithInstructionSet(set)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, borderedProgram(3, background, program...)); err != nil {
+				t.Fatalf("png.Encode() error = %v", err)
+			}
+			var out bytes.Buffer
+			i, err := NewInterpreterWithOptions(append(tt.opts, WithOutput(&out))...)
+			if err != nil {
+				t.Fatalf("NewInterpreterWithOptions() error = %v", err)
+			}
+			if err := i.LoadImageFromReader(&buf); err != nil {
+				t.Fatalf("Interpreter.LoadImageFromReader() error = %v", err)
+			}
+			if _, err := i.Run(); err != nil {
+				t.Fatalf("Interpreter.Run() error = %v", err)
+			}
+			if got := out.String(); got != "3" {
+				t.Errorf("Interpreter.Run() output = %q, want %q", got, "3")
+			}
+			if got := i.stack.Size(); got != tt.wantStack {
+				t.Errorf("stack size = %d, want %d", got, tt.wantStack)
+			}
+		})
+	}
+	if _, err := NewInterpreterWithOptions(WithRegion(Region{Width: -1})); err != ErrorInvalidRegion {
+		t.Errorf("WithRegion() error = %v, want %v", err, ErrorInvalidRegion)
+	}
+}
@@ -0,0 +1,130 @@
+package interpreter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrorFileAccessDisabled = errors.New("error: file operations are disabled")
+	ErrorFileReadOnly       = errors.New("error: trying to write a file in read-only mode")
+	ErrorFileOutsideRoot    = errors.New("error: trying to open a file outside the allowed directory")
+	ErrorInvalidFileAccess  = errors.New("error: invalid file access mode")
+	ErrorInvalidFileRoot    = errors.New("error: file root must be an existing directory")
+)
+
+// Operations allowed on the files opened by FILE_OPEN
+type FileAccess uint8
+
+const (
+	FileAccessReadWrite FileAccess = iota // files are created if missing, read and appended (default)
+	FileAccessReadOnly                    // existing files can only be read
+	FileAccessDisabled                    // FILE_OPEN always fails
+)
+
+var fileAccessNames = [...]string{
+	FileAccessReadWrite: "read_write",
+	FileAccessReadOnly:  "read_only",
+	FileAccessDisabled:  "disabled",
+}
+
+func (a FileAccess) String() string {
+	if int(a) < len(fileAccessNames) {
+		return fileAccessNames[a]
+	}
+	return "unknown"
+}
+
+// Returns the FileAccess with the given name: read_write, read_only or disabled
+func ParseFileAccess(name string) (FileAccess, error) {
+	for access, n := range fileAccessNames {
+		if n == strings.ToLower(name) {
+			return FileAccess(access), nil
+		}
+	}
+	return 0, ErrorInvalidFileAccess
+}
+
+/*
+ * Opens the file at the given path following the file policy of the interpreter.
+ * When a root directory is set, the path must be relative and it is resolved from the root:
+ * paths leaving the root, also through symbolic links, are rejected with ErrorFileOutsideRoot,
+ * as well as symbolic links to missing files.
+ */
+func openFile(i *Interpreter, path string) (*os.File, error) {
+	if i.fileAccess == FileAccessDisabled {
+		return nil, ErrorFileAccessDisabled
+	}
+	path, err := resolveFilePath(i.fileRoot, path)
+	if err != nil {
+		return nil, err
+	}
+	flag := os.O_CREATE | os.O_APPEND | os.O_RDWR
+	if i.fileAccess == FileAccessReadOnly {
+		flag = os.O_RDONLY
+	}
+	file, err := os.OpenFile(path, flag, 0644)
+	if err != nil {
+		return nil, ErrorOpenFile
+	}
+	return file, nil
+}
+
+// Resolves a path built by the program inside root. An empty root leaves the path unchanged.
+func resolveFilePath(root string, path string) (string, error) {
+	if root == "" {
+		return path, nil
+	}
+	if filepath.IsAbs(path) || filepath.VolumeName(path) != "" {
+		return "", ErrorFileOutsideRoot
+	}
+	full := filepath.Join(root, path)
+	if !isInsideDir(root, full) {
+		return "", ErrorFileOutsideRoot
+	}
+
+	// A file that doesn't exist yet is created in its directory, which must be resolved too
+	resolved, err := filepath.EvalSymlinks(full)
+	if os.IsNotExist(err) {
+		// a dangling symbolic link would create its target, wherever it is
+		if _, err := os.Lstat(full); err == nil {
+			return "", ErrorFileOutsideRoot
+		}
+		resolved, err = filepath.EvalSymlinks(filepath.Dir(full))
+		resolved = filepath.Join(resolved, filepath.Base(full))
+	}
+	if err != nil {
+		return "", ErrorOpenFile
+	}
+	if !isInsideDir(root, resolved) {
+		return "", ErrorFileOutsideRoot
+	}
+	return resolved, nil
+}
+
+// Returns true if path is dir or one of its descendants. Both paths must be clean.
+func isInsideDir(dir string, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Returns the absolute path of dir with symbolic links resolved, if it is an existing directory
+func resolveFileRoot(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", ErrorInvalidFileRoot
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", ErrorInvalidFileRoot
+	}
+	if info, err := os.Stat(resolved); err != nil || !info.IsDir() {
+		return "", ErrorInvalidFileRoot
+	}
+	return resolved, nil
+}
@@ -0,0 +1,179 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Builds the pixels pushing the given string, delimiter included, so that it can be popped by FILE_OPEN
+func stringPixels(str string) []*Pixel {
+	pixels := []*Pixel{{}}
+	for index := len(str) - 1; index >= 0; index-- {
+		p, _ := literalPixel(int(str[index]), NewInstructionSet())
+		pixels = append(pixels, &p)
+	}
+	return pixels
+}
+
+func TestInterpreter_FileSandbox(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "data.txt"), []byte("hi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "target.txt"), filepath.Join(root, "dangling")); err != nil {
+		t.Fatal(err)
+	}
+	write := []*Pixel{OPERATIONS["FILE_OPEN"], {R: 7}, OPERATIONS["OUTPUT_INT"], OPERATIONS["FILE_CLOSE"]}
+	read := []*Pixel{OPERATIONS["FILE_OPEN"], OPERATIONS["INPUT_ASCII"], OPERATIONS["FILE_CLOSE"], OPERATIONS["OUTPUT_ASCII"]}
+	tests := []struct {
+		name       string
+		opts       []Option
+		path       string
+		program    []*Pixel
+		wantErr    error
+		wantOutput string
+		wantFile   string
+	}{
+		{
+			name:     "Write inside the root",
+			opts:     []Option{WithFileRoot(root)},
+			path:     "new.txt",
+			program:  write,
+			wantFile: filepath.Join(root, "new.txt"),
+		},
+		{
+			name:     "Path going back into the root",
+			opts:     []Option{WithFileRoot(root)},
+			path:     "sub/../new_sub.txt",
+			program:  write,
+			wantFile: filepath.Join(root, "new_sub.txt"),
+		},
+		{
+			name:    "Parent directory",
+			opts:    []Option{WithFileRoot(root)},
+			path:    "../escape.txt",
+			program: write,
+			wantErr: ErrorFileOutsideRoot,
+		},
+		{
+			name:    "Absolute path",
+			opts:    []Option{WithFileRoot(root)},
+			path:    filepath.Join(outside, "escape.txt"),
+			program: write,
+			wantErr: ErrorFileOutsideRoot,
+		},
+		{
+			name:    "Symbolic link leaving the root",
+			opts:    []Option{WithFileRoot(root)},
+			path:    "link/escape.txt",
+			program: write,
+			wantErr: ErrorFileOutsideRoot,
+		},
+		{
+			name:    "Symbolic link to a missing file",
+			opts:    []Option{WithFileRoot(root)},
+			path:    "dangling",
+			program: write,
+			wantErr: ErrorFileOutsideRoot,
+		},
+		{
+			name:    "Disabled",
+			opts:    []Option{WithFileAccess(FileAccessDisabled)},
+			path:    filepath.Join(root, "data.txt"),
+			program: read,
+			wantErr: ErrorFileAccessDisabled,
+		},
+		{
+			name:       "Read in read-only mode",
+			opts:       []Option{WithFileRoot(root), WithFileAccess(FileAccessReadOnly)},
+			path:       "data.txt",
+			program:    read,
+			wantOutput: "ih",
+		},
+		{
+			name:    "Write in read-only mode",
+			opts:    []Option{WithFileRoot(root), WithFileAccess(FileAccessReadOnly)},
+			path:    "data.txt",
+			program: write,
+			wantErr: ErrorFileReadOnly,
+		},
+		{
+			name:    "Missing file in read-only mode",
+			opts:    []Option{WithFileRoot(root), WithFileAccess(FileAccessReadOnly)},
+			path:    "missing.txt",
+			program: read,
+			wantErr: ErrorOpenFile,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			i, err := NewInterpreterWithOptions(append(tt.opts, WithOutput(output))...)
+			if err != nil {
+				t.Fatalf("NewInterpreterWithOptions() error = %v", err)
+			}
+			loadTestProgram(i, append(stringPixels(tt.path), tt.program...)...)
+			if _, err := i.Run(); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Interpreter.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := output.String(); got != tt.wantOutput {
+				t.Errorf("Interpreter.Run() output = %q, want %q", got, tt.wantOutput)
+			}
+			if tt.wantFile != "" {
+				if content, err := os.ReadFile(tt.wantFile); err != nil || string(content) != "7" {
+					t.Errorf("file content = %q, %v, want \"7\"", content, err)
+				}
+			}
+			if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+				t.Errorf("program wrote outside the root: %v", entries)
+			}
+		})
+	}
+}
+
+func TestParseFileAccess(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    FileAccess
+		wantErr error
+	}{
+		{name: "read_write", want: FileAccessReadWrite},
+		{name: "READ_ONLY", want: FileAccessReadOnly},
+		{name: "disabled", want: FileAccessDisabled},
+		{name: "none", wantErr: ErrorInvalidFileAccess},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFileAccess(tt.name)
+			if err != tt.wantErr {
+				t.Fatalf("ParseFileAccess() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (got != tt.want || got.String() != strings.ToLower(tt.name)) {
+				t.Errorf("ParseFileAccess() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithFileRoot(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{file, filepath.Join(t.TempDir(), "missing")} {
+		if _, err := NewInterpreterWithOptions(WithFileRoot(dir)); err != ErrorInvalidFileRoot {
+			t.Errorf("WithFileRoot(%s) error = %v, want %v", dir, err, ErrorInvalidFileRoot)
+		}
+	}
+	if _, err := NewInterpreterWithOptions(WithFileAccess(FileAccess(10))); err != ErrorInvalidFileAccess {
+		t.Errorf("WithFileAccess(10) error = %v, want %v", err, ErrorInvalidFileAccess)
+	}
+}
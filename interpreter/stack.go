@@ -3,6 +3,7 @@ package interpreter
 import (
 	"errors"
 	"fmt"
+	"io"
 )
 
 var (
@@ -13,20 +14,37 @@ var (
 )
 
 type Stack struct {
-	items   []int32
+	items   []Value
 	maxSize int
+	numbers NumberType
 }
 
 func NewStack(maxSize int) (*Stack, error) {
+	return NewStackOfType(maxSize, NumberInt32)
+}
+
+// Returns a stack holding integers of the given type
+func NewStackOfType(maxSize int, numbers NumberType) (*Stack, error) {
 	if maxSize < -1 {
 		return nil, ErrorInvalidMaxSize
 	}
+	if int(numbers) >= len(numberTypeNames) {
+		return nil, ErrorInvalidNumberType
+	}
 	stack := new(Stack)
 	stack.maxSize = maxSize
+	stack.numbers = numbers
 	return stack, nil
 }
 
-func (stack *Stack) Push(val int32) error {
+// Returns the type of the integers held by the stack
+func (stack *Stack) NumberType() NumberType {
+	return stack.numbers
+}
+
+// Pushes the value, wrapped around to the range of the stack number type
+func (stack *Stack) Push(val Value) error {
+	val = stack.numbers.wrap(val)
 	if stack.maxSize == -1 {
 		stack.items = append(stack.items, val)
 		return nil
@@ -38,16 +56,16 @@ func (stack *Stack) Push(val int32) error {
 	//fmt.Printf("Stack (push) -> %+v\n", stack.items)
 }
 
-func (stack *Stack) Peek() int32 {
+func (stack *Stack) Peek() Value {
 	if stack.IsEmpty() {
-		return 0
+		return Value{}
 	}
 	return stack.items[len(stack.items)-1]
 }
 
-func (stack *Stack) Pop() (int32, error) {
+func (stack *Stack) Pop() (Value, error) {
 	if len(stack.items) == 0 {
-		return 0, ErrorPop
+		return Value{}, ErrorPop
 	}
 
 	index := len(stack.items) - 1 // Get the index of the top most element.
@@ -68,6 +86,9 @@ func (stack *Stack) IsEmpty() bool {
 
 func (stack *Stack) Cycle() *Stack {
 	var s = stack.items
+	if len(s) == 0 {
+		return stack
+	}
 	var lastPos = len(s) - 1
 	var last = s[lastPos]
 	copy(s[1:], s[:lastPos])
@@ -77,6 +98,9 @@ func (stack *Stack) Cycle() *Stack {
 
 func (stack *Stack) RCycle() *Stack {
 	var s = stack.items
+	if len(s) == 0 {
+		return stack
+	}
 	var lastPos = len(s) - 1
 	var last = s[0]
 	copy(s[:lastPos], s[1:])
@@ -92,16 +116,19 @@ func (stack *Stack) Reverse() *Stack {
 	return stack
 }
 
-func (stack *Stack) Output() bool {
+// Writes all the stack content, from the top to the bottom, to the given writer. Returns false if writing fails.
+func (stack *Stack) Output(w io.Writer) bool {
 	for i := len(stack.items) - 1; i >= 0; i-- {
-		fmt.Printf("%d", stack.items[i])
+		if _, err := fmt.Fprint(w, stack.items[i].String()); err != nil {
+			return false
+		}
 	}
 	return true
 }
 
-func (stack *Stack) GetItemAt(i int) (int32, error) {
+func (stack *Stack) GetItemAt(i int) (Value, error) {
 	if i < 0 || i > len(stack.items)-1 {
-		return 0, ErrorInvalidStackIndex
+		return Value{}, ErrorInvalidStackIndex
 	}
 	return stack.items[i], nil
 }
@@ -110,3 +137,20 @@ func (stack *Stack) Clear() *Stack {
 	stack.items = nil
 	return stack
 }
+
+// Returns the n-th item from the top of the stack, where 0 is the top
+func (stack *Stack) Pick(n int) (Value, error) {
+	return stack.GetItemAt(len(stack.items) - 1 - n)
+}
+
+// Moves the n-th item from the top of the stack to the top, where 0 is the top
+func (stack *Stack) Roll(n int) error {
+	index := len(stack.items) - 1 - n
+	item, err := stack.GetItemAt(index)
+	if err != nil {
+		return err
+	}
+	copy(stack.items[index:], stack.items[index+1:])
+	stack.items[len(stack.items)-1] = item
+	return nil
+}
@@ -1,10 +1,21 @@
 package interpreter
 
 import (
+	"bytes"
+	"errors"
 	"reflect"
 	"testing"
 )
 
+// Returns the stack values of the given integers
+func intValues(values ...int64) []Value {
+	items := make([]Value, len(values))
+	for index, n := range values {
+		items[index] = IntValue(n)
+	}
+	return items
+}
+
 func TestNewStack(t *testing.T) {
 	type args struct {
 		maxSize int
@@ -60,7 +71,7 @@ func TestNewStack(t *testing.T) {
 
 func TestStack_Push(t *testing.T) {
 	type args struct {
-		val int32
+		val Value
 	}
 	tests := []struct {
 		name    string
@@ -71,33 +82,33 @@ func TestStack_Push(t *testing.T) {
 		{
 			name: "No max size",
 			stack: &Stack{
-				items:   []int32{},
+				items:   intValues(),
 				maxSize: -1,
 			},
 			args: args{
-				val: 10,
+				val: IntValue(10),
 			},
 			wantErr: false,
 		},
 		{
 			name: "With max size",
 			stack: &Stack{
-				items:   []int32{10, 20, 30},
+				items:   intValues(10, 20, 30),
 				maxSize: 5,
 			},
 			args: args{
-				val: 40,
+				val: IntValue(40),
 			},
 			wantErr: false,
 		},
 		{
 			name: "With error",
 			stack: &Stack{
-				items:   []int32{10, 20},
+				items:   intValues(10, 20),
 				maxSize: 2,
 			},
 			args: args{
-				val: 30,
+				val: IntValue(30),
 			},
 			wantErr: true,
 		},
@@ -115,22 +126,22 @@ func TestStack_Peek(t *testing.T) {
 	tests := []struct {
 		name  string
 		stack *Stack
-		want  int32
+		want  Value
 	}{
 		{
 			name: "Empty stack",
 			stack: &Stack{
-				items: []int32{},
+				items: intValues(),
 			},
-			want: 0,
+			want: IntValue(0),
 		},
 		{
 			name: "Stack not empty",
 			stack: &Stack{
-				items:   []int32{10, -3, 0, 4},
+				items:   intValues(10, -3, 0, 4),
 				maxSize: 0,
 			},
-			want: 4,
+			want: IntValue(4),
 		},
 	}
 	for _, tt := range tests {
@@ -146,32 +157,32 @@ func TestStack_Pop(t *testing.T) {
 	tests := []struct {
 		name    string
 		stack   *Stack
-		want    int32
+		want    Value
 		wantErr bool
 	}{
 		{
 			name: "Empty stack",
 			stack: &Stack{
-				items:   []int32{},
+				items:   intValues(),
 				maxSize: 0,
 			},
-			want:    0,
+			want:    IntValue(0),
 			wantErr: true,
 		},
 		{
 			name: "Stack with one element",
 			stack: &Stack{
-				items: []int32{10},
+				items: intValues(10),
 			},
-			want:    10,
+			want:    IntValue(10),
 			wantErr: false,
 		},
 		{
 			name: "Stack with multiple elements",
 			stack: &Stack{
-				items: []int32{10, 20},
+				items: intValues(10, 20),
 			},
-			want:    20,
+			want:    IntValue(20),
 			wantErr: false,
 		},
 	}
@@ -198,14 +209,14 @@ func TestStack_Size(t *testing.T) {
 		{
 			name: "Empty stack",
 			stack: &Stack{
-				items: []int32{},
+				items: intValues(),
 			},
 			want: 0,
 		},
 		{
 			name: "Stack with elements",
 			stack: &Stack{
-				items:   []int32{10, 20, 30},
+				items:   intValues(10, 20, 30),
 				maxSize: 0,
 			},
 			want: 3,
@@ -229,7 +240,7 @@ func TestStack_IsEmpty(t *testing.T) {
 		{
 			name: "Empty stack",
 			stack: &Stack{
-				items:   []int32{},
+				items:   intValues(),
 				maxSize: 0,
 			},
 			want: true,
@@ -237,7 +248,7 @@ func TestStack_IsEmpty(t *testing.T) {
 		{
 			name: "Not empty stack",
 			stack: &Stack{
-				items:   []int32{10},
+				items:   intValues(10),
 				maxSize: 0,
 			},
 			want: false,
@@ -261,19 +272,28 @@ func TestStack_Cycle(t *testing.T) {
 		{
 			name: "Cycle test 1",
 			stack: &Stack{
-				items: []int32{10, 20, 30, 40, 50},
+				items: intValues(10, 20, 30, 40, 50),
 			},
 			want: &Stack{
-				items: []int32{50, 10, 20, 30, 40},
+				items: intValues(50, 10, 20, 30, 40),
 			},
 		},
 		{
 			name: "Cycle test 2",
 			stack: &Stack{
-				items: []int32{50, 40, 30, 20, 10},
+				items: intValues(50, 40, 30, 20, 10),
 			},
 			want: &Stack{
-				items: []int32{10, 50, 40, 30, 20},
+				items: intValues(10, 50, 40, 30, 20),
+			},
+		},
+		{
+			name: "Cycle empty stack",
+			stack: &Stack{
+				items: intValues(),
+			},
+			want: &Stack{
+				items: intValues(),
 			},
 		},
 	}
@@ -295,19 +315,28 @@ func TestStack_RCycle(t *testing.T) {
 		{
 			name: "RCycle test 1",
 			stack: &Stack{
-				items: []int32{10, 20, 30, 40, 50},
+				items: intValues(10, 20, 30, 40, 50),
 			},
 			want: &Stack{
-				items: []int32{20, 30, 40, 50, 10},
+				items: intValues(20, 30, 40, 50, 10),
 			},
 		},
 		{
 			name: "RCycle test 2",
 			stack: &Stack{
-				items: []int32{50, 40, 30, 20, 10},
+				items: intValues(50, 40, 30, 20, 10),
 			},
 			want: &Stack{
-				items: []int32{40, 30, 20, 10, 50},
+				items: intValues(40, 30, 20, 10, 50),
+			},
+		},
+		{
+			name: "RCycle empty stack",
+			stack: &Stack{
+				items: intValues(),
+			},
+			want: &Stack{
+				items: intValues(),
 			},
 		},
 	}
@@ -329,30 +358,30 @@ func TestStack_Reverse(t *testing.T) {
 		{
 			name: "Reverse test 1",
 			stack: &Stack{
-				items: []int32{10},
+				items: intValues(10),
 			},
 			want: &Stack{
-				items: []int32{10},
+				items: intValues(10),
 			},
 		},
 		{
 			name: "Reverse test 2",
 			stack: &Stack{
-				items:   []int32{10, 20, 30, 40, 50},
+				items:   intValues(10, 20, 30, 40, 50),
 				maxSize: 0,
 			},
 			want: &Stack{
-				items:   []int32{50, 40, 30, 20, 10},
+				items:   intValues(50, 40, 30, 20, 10),
 				maxSize: 0,
 			},
 		},
 		{
 			name: "Reverse stack empty",
 			stack: &Stack{
-				items: []int32{},
+				items: intValues(),
 			},
 			want: &Stack{
-				items: []int32{},
+				items: intValues(),
 			},
 		},
 	}
@@ -373,40 +402,40 @@ func TestStack_GetItemAt(t *testing.T) {
 		name    string
 		stack   *Stack
 		args    args
-		want    int32
+		want    Value
 		wantErr bool
 	}{
 		{
 			name: "Get item in stack",
 			stack: &Stack{
-				items: []int32{10, 20, 30, 40, 50},
+				items: intValues(10, 20, 30, 40, 50),
 			},
 			args: args{
 				i: 3,
 			},
-			want:    40,
+			want:    IntValue(40),
 			wantErr: false,
 		},
 		{
 			name: "Negative argument",
 			stack: &Stack{
-				items: []int32{10, 20, 30, 40, 50},
+				items: intValues(10, 20, 30, 40, 50),
 			},
 			args: args{
 				i: -1,
 			},
-			want:    0,
+			want:    IntValue(0),
 			wantErr: true,
 		},
 		{
 			name: "Exceeded index argument",
 			stack: &Stack{
-				items: []int32{10, 20, 30, 40, 50},
+				items: intValues(10, 20, 30, 40, 50),
 			},
 			args: args{
 				i: 5,
 			},
-			want:    0,
+			want:    IntValue(0),
 			wantErr: true,
 		},
 	}
@@ -433,7 +462,7 @@ func TestStack_Clear(t *testing.T) {
 		{
 			name: "Stack with elements",
 			stack: &Stack{
-				items: []int32{10, 20, 30},
+				items: intValues(10, 20, 30),
 			},
 			want: &Stack{
 				items: nil,
@@ -442,7 +471,7 @@ func TestStack_Clear(t *testing.T) {
 		{
 			name: "Stack without elements",
 			stack: &Stack{
-				items: []int32{},
+				items: intValues(),
 			},
 			want: &Stack{
 				items: nil,
@@ -458,25 +487,222 @@ func TestStack_Clear(t *testing.T) {
 	}
 }
 
+func TestStack_Pick(t *testing.T) {
+	tests := []struct {
+		name    string
+		stack   *Stack
+		n       int
+		want    Value
+		wantErr bool
+	}{
+		{
+			name:  "Top of the stack",
+			stack: &Stack{items: intValues(10, 20, 30)},
+			n:     0,
+			want:  IntValue(30),
+		},
+		{
+			name:  "Bottom of the stack",
+			stack: &Stack{items: intValues(10, 20, 30)},
+			n:     2,
+			want:  IntValue(10),
+		},
+		{
+			name:    "Negative depth",
+			stack:   &Stack{items: intValues(10, 20, 30)},
+			n:       -1,
+			wantErr: true,
+		},
+		{
+			name:    "Depth exceeding the stack",
+			stack:   &Stack{items: intValues(10, 20, 30)},
+			n:       3,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.stack.Pick(tt.n)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Stack.Pick() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Stack.Pick() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStack_Roll(t *testing.T) {
+	tests := []struct {
+		name    string
+		stack   *Stack
+		n       int
+		want    []Value
+		wantErr bool
+	}{
+		{
+			name:  "Top of the stack",
+			stack: &Stack{items: intValues(10, 20, 30)},
+			n:     0,
+			want:  intValues(10, 20, 30),
+		},
+		{
+			name:  "Second item",
+			stack: &Stack{items: intValues(10, 20, 30)},
+			n:     1,
+			want:  intValues(10, 30, 20),
+		},
+		{
+			name:  "Bottom of the stack",
+			stack: &Stack{items: intValues(10, 20, 30, 40)},
+			n:     3,
+			want:  intValues(20, 30, 40, 10),
+		},
+		{
+			name:    "Depth exceeding the stack",
+			stack:   &Stack{items: intValues(10, 20, 30)},
+			n:       3,
+			want:    intValues(10, 20, 30),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.stack.Roll(tt.n); (err != nil) != tt.wantErr {
+				t.Errorf("Stack.Roll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(tt.stack.items, tt.want) {
+				t.Errorf("Stack.Roll() items = %v, want %v", tt.stack.items, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpreter_RunStackOperations(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		want    []Value
+		wantErr error
+	}{
+		{
+			name:   "Over",
+			source: "push 1 push 2 over",
+			want:   intValues(1, 2, 1),
+		},
+		{
+			name:    "Over with one item",
+			source:  "push 1 over",
+			want:    intValues(1),
+			wantErr: ErrorPop,
+		},
+		{
+			name:   "Rot",
+			source: "push 1 push 2 push 3 rot",
+			want:   intValues(2, 3, 1),
+		},
+		{
+			name:    "Rot with two items",
+			source:  "push 1 push 2 rot",
+			want:    intValues(1, 2),
+			wantErr: ErrorPop,
+		},
+		{
+			name:   "Pick",
+			source: "push 1 push 2 push 3 push 2 pick",
+			want:   intValues(1, 2, 3, 1),
+		},
+		{
+			name:   "Pick the top is dup",
+			source: "push 1 push 2 push 0 pick",
+			want:   intValues(1, 2, 2),
+		},
+		{
+			name:    "Pick beyond the bottom",
+			source:  "push 1 push 2 push 2 pick",
+			want:    intValues(1, 2),
+			wantErr: ErrorInvalidStackIndex,
+		},
+		{
+			name:   "Roll",
+			source: "push 1 push 2 push 3 push 4 push 3 roll",
+			want:   intValues(2, 3, 4, 1),
+		},
+		{
+			name:   "Roll the second item is swap",
+			source: "push 1 push 2 push 1 roll",
+			want:   intValues(2, 1),
+		},
+		{
+			name:    "Roll a negative depth",
+			source:  "push 5 push 1 push 2 sub roll",
+			want:    intValues(5),
+			wantErr: ErrorInvalidStackIndex,
+		},
+		{
+			name:   "Depth",
+			source: "push 7 push 7 depth depth",
+			want:   intValues(7, 7, 2, 3),
+		},
+		{
+			name:   "Depth of the empty stack",
+			source: "depth",
+			want:   intValues(0),
+		},
+		{
+			name:   "Clear",
+			source: "push 1 push 2 clear depth",
+			want:   intValues(0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, _, err := runTestSource(t, tt.source)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Interpreter.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := append([]Value{}, i.stack.items...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Interpreter.Run() stack = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestStack_Output(t *testing.T) {
 	tests := []struct {
-		name  string
-		stack *Stack
-		want  bool
+		name       string
+		stack      *Stack
+		want       bool
+		wantOutput string
 	}{
 		{
 			name: "Output test",
 			stack: &Stack{
-				items: []int32{10, 20},
+				items: intValues(10, 20),
 			},
-			want: true,
+			want:       true,
+			wantOutput: "2010",
+		},
+		{
+			name: "Output empty stack",
+			stack: &Stack{
+				items: intValues(),
+			},
+			want:       true,
+			wantOutput: "",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.stack.Output(); got != tt.want {
+			w := &bytes.Buffer{}
+			if got := tt.stack.Output(w); got != tt.want {
 				t.Errorf("Stack.Output() = %v, want %v", got, tt.want)
 			}
+			if gotOutput := w.String(); gotOutput != tt.wantOutput {
+				t.Errorf("Stack.Output() wrote %v, want %v", gotOutput, tt.wantOutput)
+			}
 		})
 	}
 }
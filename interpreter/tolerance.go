@@ -0,0 +1,131 @@
+package interpreter
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrorAmbiguousColor   = errors.New("error: color is within the tolerance of more than one operation")
+	ErrorInvalidTolerance = errors.New("error: color tolerance can't be negative")
+	ErrorInvalidMetric    = errors.New("error: invalid color metric")
+)
+
+// Distance used to compare a color with the color codes of the operations
+type ColorMetric uint8
+
+const (
+	MetricRGB ColorMetric = iota // Euclidean distance between red, green and blue values [0, 441.7]
+	MetricLab                    // Euclidean distance in the CIE L*a*b* color space (CIE76 delta E) [0, ~258]
+)
+
+var colorMetricNames = [...]string{
+	MetricRGB: "rgb",
+	MetricLab: "lab",
+}
+
+func (m ColorMetric) String() string {
+	if int(m) < len(colorMetricNames) {
+		return colorMetricNames[m]
+	}
+	return "unknown"
+}
+
+// Returns the ColorMetric with the given name: rgb or lab
+func ParseColorMetric(name string) (ColorMetric, error) {
+	for metric, n := range colorMetricNames {
+		if n == strings.ToLower(name) {
+			return ColorMetric(metric), nil
+		}
+	}
+	return 0, ErrorInvalidMetric
+}
+
+// Coordinates of a color in a color space
+type colorPoint [3]float64
+
+/*
+ * Returns a copy of the instruction set that decodes a pixel as the operation whose color code is within
+ * the given distance, measured with the given metric. Pixels matching exactly a color code are always decoded
+ * as its operation, while pixels within the distance of more than one operation are ambiguous and can't be compiled.
+ * A distance of 0 disables the tolerance.
+ */
+func (set *InstructionSet) WithTolerance(metric ColorMetric, distance float64) (*InstructionSet, error) {
+	if int(metric) >= len(colorMetricNames) {
+		return nil, ErrorInvalidMetric
+	}
+	if distance < 0 || math.IsNaN(distance) {
+		return nil, ErrorInvalidTolerance
+	}
+	tolerant := *set
+	tolerant.metric, tolerant.tolerance = metric, distance
+	tolerant.points = make(map[string]colorPoint, len(set.colors))
+	for op, px := range set.colors {
+		tolerant.points[op] = metric.point(px)
+	}
+	return &tolerant, nil
+}
+
+// Returns the operations whose color code is within the tolerance of the given pixel, from the nearest
+func (set *InstructionSet) nearOperations(p Pixel) []string {
+	type candidate struct {
+		op       string
+		distance float64
+	}
+	var candidates []candidate
+	point := set.metric.point(p)
+	for op, opPoint := range set.points {
+		if d := distance(point, opPoint); d <= set.tolerance {
+			candidates = append(candidates, candidate{op: op, distance: d})
+		}
+	}
+	sort.Slice(candidates, func(a, b int) bool {
+		if candidates[a].distance != candidates[b].distance {
+			return candidates[a].distance < candidates[b].distance
+		}
+		return candidates[a].op < candidates[b].op
+	})
+	ops := make([]string, len(candidates))
+	for index, c := range candidates {
+		ops[index] = c.op
+	}
+	return ops
+}
+
+// Returns the coordinates of the pixel in the color space of the metric
+func (m ColorMetric) point(p Pixel) colorPoint {
+	if m == MetricLab {
+		return pixelToLab(p)
+	}
+	return colorPoint{float64(p.R), float64(p.G), float64(p.B)}
+}
+
+func distance(a colorPoint, b colorPoint) float64 {
+	return math.Sqrt((a[0]-b[0])*(a[0]-b[0]) + (a[1]-b[1])*(a[1]-b[1]) + (a[2]-b[2])*(a[2]-b[2]))
+}
+
+// Converts an sRGB color to CIE L*a*b* with D65 white point
+func pixelToLab(p Pixel) colorPoint {
+	linear := func(v uint8) float64 {
+		c := float64(v) / 255
+		if c <= 0.04045 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	r, g, b := linear(p.R), linear(p.G), linear(p.B)
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / 0.95047
+	y := 0.2126729*r + 0.7151522*g + 0.0721750*b
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / 1.08883
+
+	f := func(t float64) float64 {
+		if t > 216.0/24389 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return colorPoint{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
@@ -0,0 +1,157 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"strings"
+	"testing"
+)
+
+func TestInstructionSet_DecodeWithTolerance(t *testing.T) {
+	tests := []struct {
+		name      string
+		metric    ColorMetric
+		tolerance float64
+		pixel     Pixel
+		want      Instruction
+		wantErr   error
+		wantOps   string
+	}{
+		{name: "Exact match", metric: MetricRGB, tolerance: 10, pixel: *OPERATIONS["SUM"], want: Instruction{Op: OpSum}},
+		{name: "Near RGB", metric: MetricRGB, tolerance: 10, pixel: Pixel{R: 3, G: 200, B: 212}, want: Instruction{Op: OpSum}},
+		{name: "Far RGB", metric: MetricRGB, tolerance: 5, pixel: Pixel{R: 3, G: 200, B: 212}, want: Instruction{Op: OpPush, Value: 415}},
+		{name: "No tolerance", metric: MetricRGB, tolerance: 0, pixel: Pixel{R: 0, G: 206, B: 208}, want: Instruction{Op: OpPush, Value: 414}},
+		{name: "Near Lab", metric: MetricLab, tolerance: 8, pixel: Pixel{R: 3, G: 200, B: 212}, want: Instruction{Op: OpSum}},
+		{name: "Far Lab", metric: MetricLab, tolerance: 4, pixel: Pixel{R: 3, G: 200, B: 212}, want: Instruction{Op: OpPush, Value: 415}},
+		{
+			name:      "Ambiguous",
+			metric:    MetricRGB,
+			tolerance: 15,
+			pixel:     Pixel{R: 230, G: 137, B: 165},
+			wantErr:   ErrorAmbiguousColor,
+			wantOps:   "CYCLE or RCYCLE",
+		},
+		{
+			name:      "Exact match is never ambiguous",
+			metric:    MetricRGB,
+			tolerance: 30,
+			pixel:     *OPERATIONS["RCYCLE"],
+			want:      Instruction{Op: OpRcycle},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := NewInstructionSet().WithTolerance(tt.metric, tt.tolerance)
+			if err != nil {
+				t.Fatalf("InstructionSet.WithTolerance() error = %v", err)
+			}
+			got, err := set.Decode(&tt.pixel)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("InstructionSet.Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var compileErr *CompileError
+				if !errors.As(err, &compileErr) || compileErr.Op != tt.wantOps {
+					t.Errorf("InstructionSet.Decode() error = %v, want operations %s", err, tt.wantOps)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("InstructionSet.Decode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile_AmbiguousColor(t *testing.T) {
+	set, _ := NewInstructionSet().WithTolerance(MetricRGB, 15)
+	img := newTestImage(3, 1, &Pixel{R: 1}, &Pixel{R: 230, G: 137, B: 165}, OPERATIONS["POP"])
+	_, err := Compile(img, set, 1)
+	var compileErr *CompileError
+	if !errors.As(err, &compileErr) || !errors.Is(err, ErrorAmbiguousColor) {
+		t.Fatalf("Compile() error = %v, want ambiguous color error", err)
+	}
+	if compileErr.Pos != (image.Point{X: 1, Y: 0}) {
+		t.Errorf("CompileError.Pos = %v, want (1, 0)", compileErr.Pos)
+	}
+}
+
+// A program saved as JPEG can be compiled only with a tolerance
+func TestCompile_JPEG(t *testing.T) {
+	source := "dup swap cycle sum\nsub div pop quit"
+	img, err := Assemble(strings.NewReader(source), NewInstructionSet(), 16)
+	if err != nil {
+		t.Fatalf("Assemble() error = %v", err)
+	}
+	encoded := &bytes.Buffer{}
+	if err := jpeg.Encode(encoded, img, &jpeg.Options{Quality: 90}); err != nil {
+		t.Fatal(err)
+	}
+	lossy, err := jpeg.Decode(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Opcode{OpDup, OpSwap, OpCycle, OpSum, OpSub, OpDiv, OpPop, OpQuit}
+
+	tests := []struct {
+		name      string
+		metric    ColorMetric
+		tolerance float64
+		wantExact bool
+	}{
+		{name: "Without tolerance", metric: MetricRGB, tolerance: 0, wantExact: false},
+		{name: "RGB tolerance", metric: MetricRGB, tolerance: 20, wantExact: true},
+		{name: "Lab tolerance", metric: MetricLab, tolerance: 8, wantExact: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, _ := NewInstructionSet().WithTolerance(tt.metric, tt.tolerance)
+			program, err := Compile(lossy, set, 16)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			exact := program.Len() == len(want)
+			for index := 0; exact && index < program.Len(); index++ {
+				exact = program.Instruction(index).Op == want[index]
+			}
+			if exact != tt.wantExact {
+				t.Errorf("Compile() = %v, want same operations as the source: %v", program.code, tt.wantExact)
+			}
+		})
+	}
+}
+
+func TestInstructionSet_WithTolerance(t *testing.T) {
+	if _, err := NewInstructionSet().WithTolerance(MetricRGB, -1); err != ErrorInvalidTolerance {
+		t.Errorf("WithTolerance(-1) error = %v, want %v", err, ErrorInvalidTolerance)
+	}
+	if _, err := NewInstructionSet().WithTolerance(ColorMetric(5), 1); err != ErrorInvalidMetric {
+		t.Errorf("WithTolerance(metric 5) error = %v, want %v", err, ErrorInvalidMetric)
+	}
+	for _, name := range []string{"rgb", "LAB"} {
+		if metric, err := ParseColorMetric(name); err != nil || metric.String() != strings.ToLower(name) {
+			t.Errorf("ParseColorMetric(%s) = %s, %v", name, metric, err)
+		}
+	}
+	if _, err := ParseColorMetric("hsv"); err != ErrorInvalidMetric {
+		t.Errorf("ParseColorMetric(hsv) error = %v, want %v", err, ErrorInvalidMetric)
+	}
+}
+
+func Test_pixelToLab(t *testing.T) {
+	tests := []struct {
+		pixel Pixel
+		want  colorPoint
+	}{
+		{pixel: Pixel{R: 255, G: 255, B: 255}, want: colorPoint{100, 0, 0}},
+		{pixel: Pixel{}, want: colorPoint{0, 0, 0}},
+		{pixel: Pixel{R: 255}, want: colorPoint{53.24, 80.09, 67.20}},
+	}
+	for _, tt := range tests {
+		if got := pixelToLab(tt.pixel); distance(got, tt.want) > 0.05 {
+			t.Errorf("pixelToLab(%v) = %v, want %v", tt.pixel, got, tt.want)
+		}
+	}
+}
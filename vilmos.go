@@ -3,8 +3,14 @@ package main
 import (
 	"errors"
 	"fmt"
+	"image"
+	"image/png"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	inter "github.com/Vinetwigs/vilmos/v2/interpreter"
 
@@ -12,13 +18,16 @@ import (
 )
 
 const (
-	version string = "2.1.1"
-	usage   string = "Official vilmos language interpreter"
+	version   string = "2.1.1"
+	usage     string = "Official vilmos language interpreter"
+	stdinPath string = "-" // input path that reads the image from stdin
 )
 
 var (
 	ErrorNoImage      = errors.New("error: no specified image")
+	ErrorNoSource     = errors.New("error: no specified source")
 	ErrorWrongCommand = errors.New("error: wrong command")
+	ErrorWriteImage   = errors.New("error: unable to write the image")
 )
 
 func main() {
@@ -28,6 +37,22 @@ func main() {
 		maxSize         int
 		instructionSize int
 		imagePath       string
+		sourcePath      string
+		outputPath      string
+		maxSteps        int
+		timeout         time.Duration
+		fileRoot        string
+		fileAccess      string
+		tolerance       float64
+		colorMetric     string
+		autoSize        bool
+		transparency    string
+		region          string
+		mode            string
+		maxCallDepth    int
+		numberType      string
+		strict          bool
+		heapSize        int
 	)
 
 	cli.VersionFlag = &cli.BoolFlag{
@@ -75,30 +100,173 @@ func main() {
 				Value:       1,
 				Destination: &instructionSize,
 			},
+			&cli.BoolFlag{
+				Name:        "auto_size",
+				Aliases:     []string{"auto"},
+				Usage:       "detect the instruction size from the image, ignoring --instruction_size",
+				Value:       false,
+				Destination: &autoSize,
+			},
 			&cli.StringFlag{
 				Name:        "input",
 				Aliases:     []string{"i"},
-				Usage:       "input `FILE_PATH`",
+				Usage:       "input `FILE_PATH` (- reads the image from stdin)",
 				Value:       "",
 				Destination: &imagePath,
 			},
+			&cli.IntFlag{
+				Name:        "max_steps",
+				Aliases:     []string{"ms"},
+				Usage:       "stop the program after `N` executed instructions (0 means no limit)",
+				Value:       0,
+				Destination: &maxSteps,
+			},
+			&cli.IntFlag{
+				Name:        "max_call_depth",
+				Aliases:     []string{"mcd"},
+				Usage:       "stop the program when more than `N` CALLs are nested",
+				Value:       inter.DefaultMaxCallDepth,
+				Destination: &maxCallDepth,
+			},
+			&cli.DurationFlag{
+				Name:        "timeout",
+				Aliases:     []string{"t"},
+				Usage:       "stop the program after `DURATION` (e.g. 500ms, 10s) (0 means no limit)",
+				Value:       0,
+				Destination: &timeout,
+			},
+			&cli.StringFlag{
+				Name:        "file_root",
+				Aliases:     []string{"fr"},
+				Usage:       "confine the files opened by the program in `DIR`",
+				Value:       "",
+				Destination: &fileRoot,
+			},
+			&cli.StringFlag{
+				Name:        "file_access",
+				Aliases:     []string{"fa"},
+				Usage:       "set the file operations allowed to the program: read_write, read_only or disabled",
+				Value:       "read_write",
+				Destination: &fileAccess,
+			},
+			&cli.Float64Flag{
+				Name:        "tolerance",
+				Aliases:     []string{"tol"},
+				Usage:       "match pixels to the operation whose color code is within `DISTANCE` (0 means exact match)",
+				Value:       0,
+				Destination: &tolerance,
+			},
+			&cli.StringFlag{
+				Name:        "color_metric",
+				Aliases:     []string{"cm"},
+				Usage:       "set the distance used by --tolerance: rgb or lab",
+				Value:       "rgb",
+				Destination: &colorMetric,
+			},
+			&cli.StringFlag{
+				Name:        "transparency",
+				Aliases:     []string{"tr"},
+				Usage:       "set the meaning of transparent instructions: nop, opaque (alpha is ignored) or error",
+				Value:       "nop",
+				Destination: &transparency,
+			},
+			&cli.StringFlag{
+				Name:        "region",
+				Aliases:     []string{"rg"},
+				Usage:       "run only the instructions inside `X,Y[,WIDTH,HEIGHT]`, starting from the pixel (X, Y)",
+				Value:       "",
+				Destination: &region,
+			},
+			&cli.StringFlag{
+				Name:        "mode",
+				Aliases:     []string{"md"},
+				Usage:       "set the execution order: linear (row by row) or directional (following the direction pointer)",
+				Value:       "linear",
+				Destination: &mode,
+			},
+			&cli.StringFlag{
+				Name:        "number_type",
+				Aliases:     []string{"nt"},
+				Usage:       "set the integers held by the stack: int32, int64 or big (arbitrary precision)",
+				Value:       "int32",
+				Destination: &numberType,
+			},
+			&cli.BoolFlag{
+				Name:        "strict",
+				Aliases:     []string{"st"},
+				Usage:       "stop with an error when an operation overflows the number type instead of wrapping around",
+				Value:       false,
+				Destination: &strict,
+			},
+			&cli.IntFlag{
+				Name:        "heap_size",
+				Aliases:     []string{"hs"},
+				Usage:       "set the heap to `N` cells, addressed by STORE and LOAD from 0",
+				Value:       inter.DefaultHeapSize,
+				Destination: &heapSize,
+			},
 		},
 		Action: func(c *cli.Context) error {
 			if imagePath != "" {
-				i := inter.NewInterpreter(debug, maxSize, instructionSize)
+				if autoSize {
+					instructionSize = inter.AutoInstructionSize
+				}
+				opts := []inter.Option{
+					inter.WithDebug(debug),
+					inter.WithMaxSize(maxSize),
+					inter.WithInstructionSize(instructionSize),
+					inter.WithMaxSteps(maxSteps),
+					inter.WithTimeout(timeout),
+					inter.WithMaxCallDepth(maxCallDepth),
+					inter.WithStrictArithmetic(strict),
+					inter.WithHeapSize(heapSize),
+				}
 
-				if configPath != "" {
-					err := inter.LoadConfigs(configPath)
-					if err != nil {
-						logError(err)
-					}
+				access, err := inter.ParseFileAccess(fileAccess)
+				if err != nil {
+					logError(err)
+				}
+				opts = append(opts, inter.WithFileAccess(access))
+				executionMode, err := inter.ParseExecutionMode(mode)
+				if err != nil {
+					logError(err)
+				}
+				opts = append(opts, inter.WithExecutionMode(executionMode))
+				numbers, err := inter.ParseNumberType(numberType)
+				if err != nil {
+					logError(err)
+				}
+				opts = append(opts, inter.WithNumberType(numbers))
+				if fileRoot != "" {
+					opts = append(opts, inter.WithFileRoot(fileRoot))
+				}
+				if region != "" {
+					opts = append(opts, inter.WithRegion(parseRegion(region)))
+				}
+				set := withTransparency(withTolerance(loadInstructionSet(configPath), colorMetric, tolerance), transparency)
+				opts = append(opts, inter.WithInstructionSet(set))
+
+				i, err := inter.NewInterpreterWithOptions(opts...)
+				if err != nil {
+					logError(err)
+				}
+
+				if imagePath == stdinPath {
+					err = i.LoadImageFromReader(os.Stdin)
+				} else {
+					err = i.LoadImage(imagePath)
+				}
+				if err != nil {
+					logError(err)
 				}
 
-				err := i.LoadImage(imagePath)
+				exitCode, err := i.Run()
 				if err != nil {
 					logError(err)
 				}
-				i.Run()
+				if exitCode != 0 {
+					os.Exit(exitCode)
+				}
 			} else {
 				logError(ErrorNoImage)
 			}
@@ -114,6 +282,126 @@ func main() {
 					return nil
 				},
 			},
+			{
+				Name:  "asm",
+				Usage: "assemble a textual program into a .png image",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "input",
+						Aliases:     []string{"i"},
+						Usage:       "source `FILE_PATH`",
+						Value:       "",
+						Destination: &sourcePath,
+					},
+					&cli.StringFlag{
+						Name:        "output",
+						Aliases:     []string{"o"},
+						Usage:       "output image `FILE_PATH` (defaults to the source path with .png extension)",
+						Value:       "",
+						Destination: &outputPath,
+					},
+					&cli.IntFlag{
+						Name:        "instruction_size",
+						Aliases:     []string{"is", "size", "s"},
+						Usage:       "set instruction `SIZE`",
+						Value:       1,
+						Destination: &instructionSize,
+					},
+					&cli.StringFlag{
+						Name:        "config",
+						Aliases:     []string{"conf", "c"},
+						Usage:       "load configuration from `FILE_PATH` for custom color codes",
+						Value:       "",
+						Destination: &configPath,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if sourcePath == "" {
+						logError(ErrorNoSource)
+					}
+					if outputPath == "" {
+						outputPath = strings.TrimSuffix(sourcePath, filepath.Ext(sourcePath)) + ".png"
+					}
+					assemble(sourcePath, outputPath, loadInstructionSet(configPath), instructionSize)
+					return nil
+				},
+			},
+			{
+				Name:  "disasm",
+				Usage: "print the instructions of a .png image as text",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "input",
+						Aliases:     []string{"i"},
+						Usage:       "input `FILE_PATH` (- reads the image from stdin)",
+						Value:       "",
+						Destination: &imagePath,
+					},
+					&cli.IntFlag{
+						Name:        "instruction_size",
+						Aliases:     []string{"is", "size", "s"},
+						Usage:       "set instruction `SIZE`",
+						Value:       1,
+						Destination: &instructionSize,
+					},
+					&cli.BoolFlag{
+						Name:        "auto_size",
+						Aliases:     []string{"auto"},
+						Usage:       "detect the instruction size from the image, ignoring --instruction_size",
+						Value:       false,
+						Destination: &autoSize,
+					},
+					&cli.StringFlag{
+						Name:        "config",
+						Aliases:     []string{"conf", "c"},
+						Usage:       "load configuration from `FILE_PATH` for custom color codes",
+						Value:       "",
+						Destination: &configPath,
+					},
+					&cli.Float64Flag{
+						Name:        "tolerance",
+						Aliases:     []string{"tol"},
+						Usage:       "match pixels to the operation whose color code is within `DISTANCE` (0 means exact match)",
+						Value:       0,
+						Destination: &tolerance,
+					},
+					&cli.StringFlag{
+						Name:        "color_metric",
+						Aliases:     []string{"cm"},
+						Usage:       "set the distance used by --tolerance: rgb or lab",
+						Value:       "rgb",
+						Destination: &colorMetric,
+					},
+					&cli.StringFlag{
+						Name:        "transparency",
+						Aliases:     []string{"tr"},
+						Usage:       "set the meaning of transparent instructions: nop, opaque (alpha is ignored) or error",
+						Value:       "nop",
+						Destination: &transparency,
+					},
+					&cli.StringFlag{
+						Name:        "region",
+						Aliases:     []string{"rg"},
+						Usage:       "print only the instructions inside `X,Y[,WIDTH,HEIGHT]`",
+						Value:       "",
+						Destination: &region,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if imagePath == "" {
+						logError(ErrorNoImage)
+					}
+					if autoSize {
+						instructionSize = inter.AutoInstructionSize
+					}
+					set := withTransparency(withTolerance(loadInstructionSet(configPath), colorMetric, tolerance), transparency)
+					program := loadProgram(imagePath, set, instructionSize, region)
+					if err := program.Disassemble(os.Stdout); err != nil {
+						logError(err)
+					}
+					return nil
+				},
+			},
 		},
 		CommandNotFound: func(c *cli.Context, command string) {
 			logError(ErrorWrongCommand)
@@ -126,6 +414,104 @@ func main() {
 	}
 }
 
+// Loads custom color codes from the given config file. Without a config file the default color codes are used.
+func loadInstructionSet(configPath string) *inter.InstructionSet {
+	if configPath == "" {
+		return inter.NewInstructionSet()
+	}
+	set, err := inter.LoadInstructionSet(configPath)
+	if err != nil {
+		logError(err)
+	}
+	return set
+}
+
+// Applies the color tolerance flags to the instruction set
+func withTolerance(set *inter.InstructionSet, metricName string, tolerance float64) *inter.InstructionSet {
+	metric, err := inter.ParseColorMetric(metricName)
+	if err != nil {
+		logError(err)
+	}
+	set, err = set.WithTolerance(metric, tolerance)
+	if err != nil {
+		logError(err)
+	}
+	return set
+}
+
+// Applies the transparency flag to the instruction set
+func withTransparency(set *inter.InstructionSet, name string) *inter.InstructionSet {
+	transparency, err := inter.ParseTransparency(name)
+	if err != nil {
+		logError(err)
+	}
+	set, err = set.WithTransparency(transparency)
+	if err != nil {
+		logError(err)
+	}
+	return set
+}
+
+// Parses the region flag
+func parseRegion(s string) inter.Region {
+	region, err := inter.ParseRegion(s)
+	if err != nil {
+		logError(err)
+	}
+	return region
+}
+
+// Loads the image at the given path, or from stdin, and compiles the instructions inside the region into a program
+func loadProgram(imagePath string, set *inter.InstructionSet, instructionSize int, region string) *inter.Program {
+	var r io.Reader = os.Stdin
+	if imagePath != stdinPath {
+		f, err := os.Open(imagePath)
+		if err != nil {
+			logError(inter.ErrorOpenImage)
+		}
+		defer f.Close()
+		r = f
+	}
+	img, _, err := image.Decode(r)
+	if err != nil {
+		logError(inter.ErrorDecodeImage)
+	}
+	if region != "" {
+		img, err = inter.Crop(img, parseRegion(region))
+		if err != nil {
+			logError(err)
+		}
+	}
+	program, err := inter.Compile(img, set, instructionSize)
+	if err != nil {
+		logError(err)
+	}
+	return program
+}
+
+// Assembles the source file and writes the resulting program in a .png image
+func assemble(sourcePath string, outputPath string, set *inter.InstructionSet, instructionSize int) {
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		logError(inter.ErrorOpenFile)
+	}
+	defer source.Close()
+
+	img, err := inter.Assemble(source, set, instructionSize)
+	if err != nil {
+		logError(err)
+	}
+
+	out, err := os.Create(outputPath)
+	if err != nil {
+		logError(ErrorWriteImage)
+	}
+	defer out.Close()
+	if err := png.Encode(out, img); err != nil {
+		logError(ErrorWriteImage)
+	}
+}
+
 func logError(e error) {
 	fmt.Printf("\n")
 	log.Println("\033[31m" + e.Error() + "\033[0m")